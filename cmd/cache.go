@@ -0,0 +1,686 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dyammarcano/application-manager/internal/algorithm/crypto"
+	"github.com/dyammarcano/application-manager/internal/cache"
+	"github.com/dyammarcano/application-manager/internal/command"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+const (
+	encodingAuto   = "auto"
+	encodingBase64 = "base64"
+)
+
+// dumpEntry is an entry written by dump, Encoding is base64 when the value is base64 encoded
+type dumpEntry struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+var cacheCmd = command.NewCommandBuilder("cache").
+	AddCommandShortMessage("Inspect and maintain the application cache").
+	AddCommandLongMessage(`Inspect and maintain the cache used by the services.
+
+The cache directory is set with --cache-dir, by default the "cache" directory
+in the current path is used, the same one opened by the service manager. The
+service locks the cache, stop it before using these commands.
+Encrypted caches are opened with the application keyring key set by --key-id.`).
+	AddCommandFlagPersistent("cache-dir", "", "cache directory").
+	AddCommandFlagPersistent("namespace", "", "only use the keys of the namespace").
+	AddCommandFlagPersistent("key-id", int64(-1), "keyring key id of an encrypted cache, -1 when not encrypted").
+	Build()
+
+var cacheRotateKeyCmd = command.NewCommandBuilder("rotate-key").
+	AddCommandShortMessage("Change the keyring key the cache is encrypted with").
+	AddCommandLongMessage(`Change the keyring key the cache is encrypted with, the cache must not be in use.
+
+The current key is set by --key-id and the new one by --new-key-id, use -1 to
+encrypt a plain cache or to stop encrypting one. Existing data is re-encrypted
+by Badger as the tables are compacted.`).
+	AddCommandRunE(runCacheRotateKey).
+	AddCommandFlag("new-key-id", int64(-1), "keyring key id to encrypt the cache with, -1 disables encryption").
+	SilentUsage().
+	Build()
+
+var cacheBackupCmd = command.NewCommandBuilder("backup").
+	AddCommandShortMessage("Back up the cache to a file").
+	AddCommandLongMessage(`Back up the cache to a file, or to stdout when --output is not set.
+
+A full backup is taken by default, pass the value printed by a previous backup
+to --since to take an incremental backup with only the entries changed after it.`).
+	AddCommandRunE(runCacheBackup).
+	AddCommandFlag("output", "", "backup file").
+	AddCommandFlag("since", int64(0), "only back up entries with a version >= since").
+	AddCommandFlag("compress", false, "compress the backup").
+	AddCommandFlag("encrypt", false, "encrypt the backup with the application keys").
+	SilentUsage().
+	Build()
+
+var cacheRestoreCmd = command.NewCommandBuilder("restore").
+	AddCommandShortMessage("Restore the cache from a backup file").
+	AddCommandLongMessage(`Restore the cache from a backup file, or from stdin when --input is not set.
+
+Incremental backups must be restored in the order they were taken, after the
+full backup they are based on.`).
+	AddCommandRunE(runCacheRestore).
+	AddCommandFlag("input", "", "backup file").
+	SilentUsage().
+	Build()
+
+var cacheGetCmd = command.NewCommandBuilder("get").
+	AddCommandShortMessage("Print the value of a key").
+	AddCommandArgs(cobra.ExactArgs(1)).
+	AddCommandRunE(runCacheGet).
+	SilentUsage().
+	Build()
+
+var cacheSetCmd = command.NewCommandBuilder("set").
+	AddCommandShortMessage("Set the value of a key").
+	AddCommandArgs(cobra.ExactArgs(2)).
+	AddCommandRunE(runCacheSet).
+	SilentUsage().
+	Build()
+
+var cacheDelCmd = command.NewCommandBuilder("del").
+	AddCommandShortMessage("Delete one or more keys").
+	AddCommandArgs(cobra.MinimumNArgs(1)).
+	AddCommandRunE(runCacheDel).
+	SilentUsage().
+	Build()
+
+var cacheLsCmd = command.NewCommandBuilder("ls").
+	AddCommandShortMessage("List the keys of the cache").
+	AddCommandLongMessage(`List the keys of the cache in ascending order, or descending with --reverse.
+
+With --limit only one page of keys is printed followed by a cursor, pass it to
+--cursor to print the next page.`).
+	AddCommandRunE(runCacheLs).
+	AddCommandFlag("prefix", "", "only list the keys starting with prefix").
+	AddCommandFlag("limit", int64(0), "maximum number of keys to list, 0 lists all the keys").
+	AddCommandFlag("cursor", "", "cursor returned by a previous ls").
+	AddCommandFlag("reverse", false, "list the keys in descending order").
+	SilentUsage().
+	Build()
+
+var cacheCountCmd = command.NewCommandBuilder("count").
+	AddCommandShortMessage("Print the number of keys").
+	AddCommandRunE(runCacheCount).
+	AddCommandFlag("prefix", "", "only count the keys starting with prefix").
+	SilentUsage().
+	Build()
+
+var cacheDumpCmd = command.NewCommandBuilder("dump").
+	AddCommandShortMessage("Dump the entries of the cache as json or csv").
+	AddCommandRunE(runCacheDump).
+	AddCommandFlag("prefix", "", "only dump the keys starting with prefix").
+	AddCommandFlag("format", "json", "output format, json or csv").
+	AddCommandFlag("encoding", encodingAuto, "value encoding, auto encodes only the values that are not valid utf-8 in base64").
+	AddCommandFlagEnum("format", "json", "csv").
+	AddCommandFlagEnum("encoding", encodingAuto, encodingBase64).
+	AddCommandFlag("output", "", "output file, stdout when not set").
+	SilentUsage().
+	Build()
+
+var cacheLoadCmd = command.NewCommandBuilder("load").
+	AddCommandShortMessage("Load entries produced by dump into the cache").
+	AddCommandRunE(runCacheLoad).
+	AddCommandFlag("format", "json", "input format, json or csv").
+	AddCommandFlagEnum("format", "json", "csv").
+	AddCommandFlag("input", "", "input file, stdin when not set").
+	SilentUsage().
+	Build()
+
+var cacheStatsCmd = command.NewCommandBuilder("stats").
+	AddCommandShortMessage("Print the cache statistics").
+	AddCommandRunE(runCacheStats).
+	SilentUsage().
+	Build()
+
+func init() {
+	cacheCmd.AddCommand(cacheGetCmd).
+		AddCommand(cacheSetCmd).
+		AddCommand(cacheDelCmd).
+		AddCommand(cacheLsCmd).
+		AddCommand(cacheCountCmd).
+		AddCommand(cacheDumpCmd).
+		AddCommand(cacheLoadCmd).
+		AddCommand(cacheStatsCmd).
+		AddCommand(cacheBackupCmd).
+		AddCommand(cacheRestoreCmd).
+		AddCommand(cacheRotateKeyCmd)
+
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// openCache opens the cache in the directory set by the cache-dir flag, the returned view is scoped
+// to the namespace flag and root must be closed by the caller
+func openCache(cmd *cobra.Command, opts ...cache.Option) (view, root *cache.V3Cache, err error) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	keyID, _ := cmd.Flags().GetInt64("key-id")
+
+	if keyID >= 0 {
+		opts = append(opts, cache.KeyringKey(int(keyID)))
+	}
+
+	dir := cacheDir(cmd)
+
+	root, err = cache.NewCache(dir, opts...)
+	if errors.Is(err, cache.ErrorCacheInUse) {
+		return nil, nil, fmt.Errorf("the cache in %s is used by another process, stop the service using it and try again", dir)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if namespace == "" {
+		return root, root, nil
+	}
+
+	view, err = root.Namespace(namespace)
+	if err != nil {
+		_ = root.Close()
+		return nil, nil, err
+	}
+	return view, root, nil
+}
+
+// cacheDir returns the directory set by the cache-dir flag or the default one
+func cacheDir(cmd *cobra.Command) string {
+	dir, _ := cmd.Flags().GetString("cache-dir")
+	if dir == "" {
+		return cache.DefaultDir
+	}
+	return dir
+}
+
+// keyringKey returns the keyring storage key with id, nil when id is negative
+func keyringKey(id int64) ([]byte, error) {
+	if id < 0 {
+		return nil, nil
+	}
+	return crypto.StorageKey(int(id))
+}
+
+// outputWriter returns the file set by the output flag or stdout
+func outputWriter(cmd *cobra.Command) (io.Writer, func() error, error) {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		return cmd.OutOrStdout(), func() error { return nil }, nil
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// inputReader returns the file set by the input flag or stdin
+func inputReader(cmd *cobra.Command) (io.Reader, func() error, error) {
+	input, _ := cmd.Flags().GetString("input")
+	if input == "" {
+		return cmd.InOrStdin(), func() error { return nil }, nil
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+func runCacheGet(cmd *cobra.Command, args []string) error {
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	value, err := v3c.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+	return nil
+}
+
+func runCacheSet(cmd *cobra.Command, args []string) error {
+	v3c, root, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	return v3c.Set(args[0], args[1])
+}
+
+func runCacheDel(cmd *cobra.Command, args []string) error {
+	v3c, root, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	for _, key := range args {
+		if err := v3c.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runCacheLs(cmd *cobra.Command, args []string) error {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	limit, _ := cmd.Flags().GetInt64("limit")
+	cursor, _ := cmd.Flags().GetString("cursor")
+	reverse, _ := cmd.Flags().GetBool("reverse")
+
+	opts := []cache.ScanOption{cache.KeysOnly()}
+	if reverse {
+		opts = append(opts, cache.Reverse())
+	}
+
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if limit <= 0 && cursor == "" {
+		return v3c.Iterate(cmd.Context(), prefix, func(key, value string) bool {
+			fmt.Fprintln(cmd.OutOrStdout(), key)
+			return true
+		}, opts...)
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var page *cache.Page
+	if cursor != "" {
+		page, err = v3c.ScanCursor(cursor, int(limit))
+	} else {
+		page, err = v3c.Scan(prefix, "", int(limit), opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range page.Entries {
+		fmt.Fprintln(cmd.OutOrStdout(), entry.Key)
+	}
+
+	// the hint is not mixed with the keys
+	if page.Cursor != "" {
+		cmd.PrintErrf("next page: --cursor %s\n", page.Cursor)
+	}
+	return nil
+}
+
+func runCacheCount(cmd *cobra.Command, args []string) error {
+	prefix, _ := cmd.Flags().GetString("prefix")
+
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	count := 0
+	err = v3c.Iterate(cmd.Context(), prefix, func(key, value string) bool {
+		count++
+		return true
+	}, cache.KeysOnly())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), count)
+	return nil
+}
+
+func runCacheDump(cmd *cobra.Command, args []string) (err error) {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	format, _ := cmd.Flags().GetString("format")
+	encoding, _ := cmd.Flags().GetString("encoding")
+
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	w, closeOutput, err := outputWriter(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the data not written yet when the file is closed would be lost silently
+		err = errors.Join(err, closeOutput())
+	}()
+
+	if format == "csv" {
+		return dumpCSV(cmd.Context(), v3c, prefix, encoding, w)
+	}
+	return dumpJSON(cmd.Context(), v3c, prefix, encoding, w)
+}
+
+// newDumpEntry encodes the value in base64 when encoding is base64 or the value is not valid utf-8,
+// json and csv would replace the invalid bytes
+func newDumpEntry(key, value, encoding string) dumpEntry {
+	if encoding == encodingBase64 || !utf8.ValidString(value) {
+		return dumpEntry{Key: key, Value: base64.StdEncoding.EncodeToString([]byte(value)), Encoding: encodingBase64}
+	}
+	return dumpEntry{Key: key, Value: value}
+}
+
+// value returns the decoded value of the entry
+func (e dumpEntry) value() (string, error) {
+	switch e.Encoding {
+	case "":
+		return e.Value, nil
+	case encodingBase64:
+		value, err := base64.StdEncoding.DecodeString(e.Value)
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 value of key %s: %w", e.Key, err)
+		}
+		return string(value), nil
+	default:
+		return "", fmt.Errorf("invalid encoding %q of key %s", e.Encoding, e.Key)
+	}
+}
+
+// dumpJSON writes the entries as a json array, one entry per line
+func dumpJSON(ctx context.Context, v3c *cache.V3Cache, prefix, encoding string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	first := true
+
+	_, _ = bw.WriteString("[")
+
+	var writeErr error
+	err := v3c.Iterate(ctx, prefix, func(key, value string) bool {
+		data, err := json.Marshal(newDumpEntry(key, value, encoding))
+		if err != nil {
+			writeErr = err
+			return false
+		}
+
+		if !first {
+			_, _ = bw.WriteString(",")
+		}
+		first = false
+
+		_, _ = bw.WriteString("\n  ")
+		_, writeErr = bw.Write(data)
+		return writeErr == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	_, _ = bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// dumpCSV writes the entries as csv with a key,value,encoding header
+func dumpCSV(ctx context.Context, v3c *cache.V3Cache, prefix, encoding string, w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"key", "value", "encoding"}); err != nil {
+		return err
+	}
+
+	var writeErr error
+	err := v3c.Iterate(ctx, prefix, func(key, value string) bool {
+		entry := newDumpEntry(key, value, encoding)
+		writeErr = cw.Write([]string{entry.Key, entry.Value, entry.Encoding})
+		return writeErr == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func runCacheLoad(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+
+	v3c, root, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	r, closeInput, err := inputReader(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	batch := v3c.Batch()
+	defer batch.Cancel()
+
+	count := 0
+	set := func(key, value string) error {
+		count++
+		return batch.Set(key, value)
+	}
+
+	if format == "csv" {
+		err = loadCSV(r, set)
+	} else {
+		err = loadJSON(r, set)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := batch.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%d entries loaded\n", count)
+	return nil
+}
+
+// loadJSON reads a json array of entries as written by dump
+func loadJSON(r io.Reader, set func(key, value string) error) error {
+	dec := json.NewDecoder(r)
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		entry := dumpEntry{}
+		if err := dec.Decode(&entry); err != nil {
+			return err
+		}
+
+		value, err := entry.value()
+		if err != nil {
+			return err
+		}
+
+		if err := set(entry.Key, value); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// loadCSV reads key,value,encoding records as written by dump, the header and the encoding are optional
+func loadCSV(r io.Reader, set func(key, value string) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(record) != 2 && len(record) != 3 {
+			return fmt.Errorf("invalid csv record on line %d, expected key,value[,encoding]", line+1)
+		}
+
+		if line == 0 && record[0] == "key" && record[1] == "value" {
+			continue
+		}
+
+		entry := dumpEntry{Key: record[0], Value: record[1]}
+		if len(record) == 3 {
+			entry.Encoding = record[2]
+		}
+
+		value, err := entry.value()
+		if err != nil {
+			return err
+		}
+
+		if err := set(entry.Key, value); err != nil {
+			return err
+		}
+	}
+}
+
+func runCacheRotateKey(cmd *cobra.Command, args []string) error {
+	keyID, _ := cmd.Flags().GetInt64("key-id")
+	newKeyID, _ := cmd.Flags().GetInt64("new-key-id")
+
+	oldKey, err := keyringKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	newKey, err := keyringKey(newKeyID)
+	if err != nil {
+		return err
+	}
+
+	if err := cache.RotateKey(cacheDir(cmd), oldKey, newKey); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "encryption key rotated")
+	return nil
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	keys, err := v3c.Length()
+	if err != nil {
+		return err
+	}
+
+	stats := v3c.Stats()
+	w := cmd.OutOrStdout()
+
+	fmt.Fprintf(w, "keys:       %d\n", keys)
+	fmt.Fprintf(w, "table keys: %d\n", stats.TableKeys)
+	fmt.Fprintf(w, "tables:     %d\n", stats.Tables)
+	fmt.Fprintf(w, "lsm:        %d bytes\n", stats.LSMSize)
+	fmt.Fprintf(w, "vlog:       %d bytes\n", stats.VLogSize)
+	return nil
+}
+
+func runCacheBackup(cmd *cobra.Command, args []string) (err error) {
+	since, _ := cmd.Flags().GetInt64("since")
+	compress, _ := cmd.Flags().GetBool("compress")
+	encrypt, _ := cmd.Flags().GetBool("encrypt")
+
+	opts := make([]cache.BackupOption, 0, 2)
+	if compress {
+		opts = append(opts, cache.WithCompression())
+	}
+
+	if encrypt {
+		opts = append(opts, cache.WithEncryption())
+	}
+
+	v3c, root, err := openCache(cmd, cache.ReadOnly())
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	w, closeOutput, err := outputWriter(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the data not written yet when the file is closed would be lost silently
+		err = errors.Join(err, closeOutput())
+	}()
+
+	version, err := v3c.Backup(w, uint64(since), opts...)
+	if err != nil {
+		return err
+	}
+
+	next := uint64(since)
+	if version > 0 {
+		next = version + 1
+	}
+
+	// the backup may be written to stdout
+	cmd.PrintErrf("backup done, next incremental backup: --since %d\n", next)
+	return nil
+}
+
+func runCacheRestore(cmd *cobra.Command, args []string) error {
+	v3c, root, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	r, closeInput, err := inputReader(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	if err := v3c.Restore(r); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "restore done")
+	return nil
+}
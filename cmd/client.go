@@ -9,13 +9,9 @@ import (
 )
 
 var clientCmd = command.NewCommandBuilder("client").
-	AddCommandShortMessage("A brief description of your command").
-	AddCommandLongMessage(`A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This service is a tool to generate the needed files
-to quickly create a Cobra service.`).
+	AddCommandShortMessage("Run the client api service").
+	AddCommandLongMessage(`Run the client api service under the service manager, it prints the current
+time and ends.`).
 	AddCommandRun(func(cmd *cobra.Command, args []string) {
 		service.RegisterService("client api", callClient)
 	}).
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/dyammarcano/application-manager/internal/command"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var docsCmd = command.NewCommandBuilder("docs").
+	AddCommandShortMessage("Generate the man pages, Markdown reference and shell completion scripts").
+	AddCommandLongMessage(`Generate the documentation of the commands to the directory set by --dir.
+
+The man and markdown formats write a page per command, the bash, zsh and fish
+formats write the completion script of the shell, for example:
+
+  main docs --format man --dir /usr/local/share/man/man1
+  main docs --format zsh --dir ~/.zsh/completions`).
+	AddCommandRunE(runDocs).
+	AddCommandFlag("format", command.DocsMarkdown, "docs format: "+strings.Join(command.DocsFormats, ", ")).
+	AddCommandFlag("dir", "docs", "output directory").
+	AddCommandFlagEnum("format", command.DocsFormats...).
+	Build()
+
+func init() {
+	rootCmd.AddCommand(docsCmd)
+}
+
+func runDocs(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if err := rootCmd.GenerateDocs(format, dir); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%s docs written to %s\n", format, dir)
+	return nil
+}
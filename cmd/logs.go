@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/dyammarcano/application-manager/internal/command"
+	"github.com/dyammarcano/application-manager/internal/logger"
+	"github.com/dyammarcano/application-manager/internal/service"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logsCmd = command.NewCommandBuilder("logs").
+	AddCommandShortMessage("Print, filter and follow the application logs").
+	AddCommandLongMessage(`Print the log files written by the service manager, or the files passed as
+arguments.
+
+The files of the log directory are read, the one set by --log-dir, in the env
+or in the config of the service manager, like --config or the app.env file,
+by default the current path. Rotated files are printed before
+the current one and gzip compressed files are decompressed.
+
+The structured lines written by the json, logfmt and console encoders can be
+filtered with --since, a duration like 1h or a RFC3339 time, --level, the
+minimum level, and --service. With --follow the lines appended to the current
+files are printed until the command is interrupted.`).
+	AddCommandRunE(runLogs).
+	AddCommandFlag("log-dir", "", "log directory").
+	AddCommandFlag("follow", false, "print the lines appended to the log files").
+	AddCommandFlag("since", "", "only print the lines written after a duration ago like 1h or a RFC3339 time").
+	AddCommandFlag("level", "", "only print the lines with this level or above: debug, info, warn or error").
+	AddCommandFlag("service", "", "only print the lines of the service").
+	AddCommandFlagValidator("level", validateLogLevel).
+	Build()
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	filter, err := logsFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	files := args
+	if len(files) == 0 {
+		// the flag and the env are bound to the config values
+		if err := service.LoadConfig(); err != nil {
+			return err
+		}
+
+		dir := service.GetString("log-dir")
+		if dir == "" {
+			dir = "."
+		}
+
+		if files, err = logger.LogFiles(dir); err != nil {
+			return err
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no log files found")
+	}
+
+	w := cmd.OutOrStdout()
+	offsets, err := logger.ReadLogs(cmd.Context(), files, filter, w)
+	if err != nil {
+		return err
+	}
+
+	if follow, _ := cmd.Flags().GetBool("follow"); !follow {
+		return nil
+	}
+
+	// the lines of the followed files are written whole
+	lw := &lockedWriter{w: w}
+
+	g, ctx := errgroup.WithContext(cmd.Context())
+	for _, path := range files {
+		if strings.HasSuffix(path, ".gz") {
+			continue
+		}
+
+		path := path
+		g.Go(func() error {
+			// the lines written since the file was read are not lost
+			return logger.FollowLog(ctx, path, offsets[path], filter, lw)
+		})
+	}
+	return g.Wait()
+}
+
+// logsFilter returns the filter set by the since, level and service flags
+func logsFilter(cmd *cobra.Command) (*logger.Filter, error) {
+	since, _ := cmd.Flags().GetString("since")
+	level, _ := cmd.Flags().GetString("level")
+	service, _ := cmd.Flags().GetString("service")
+
+	filter := &logger.Filter{Service: service}
+
+	if since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			filter.Since = time.Now().Add(-d)
+		} else if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return nil, fmt.Errorf("invalid since %q, use a duration like 1h or a RFC3339 time", since)
+		}
+	}
+
+	if level != "" {
+		l, err := logger.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		filter.Level = &l
+	}
+	return filter, nil
+}
+
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.w.Write(p)
+}
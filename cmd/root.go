@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"github.com/dyammarcano/application-manager/internal/command"
 	"github.com/dyammarcano/application-manager/internal/logger"
 	"github.com/dyammarcano/application-manager/internal/service"
@@ -11,20 +10,41 @@ import (
 )
 
 var rootCmd = command.NewCommandBuilder("main").
-	AddCommandShortMessage("A brief description of your service").
-	AddCommandLongMessage(`A longer description that spans multiple lines and likely contains
-examples and usage of using your service. For example:
+	AddCommandShortMessage("Run the application services under the service manager").
+	AddCommandLongMessage(`Run the application services under the service manager.
 
-Cobra is a CLI library for Go that empowers applications.
-This service is a tool to generate the needed files
-to quickly create a Cobra service.`).
+The config is read from the file set by --config, the encoded string set by
+--config-string or the app.env file in the current path. Every flag can also be
+set in the config with its name, or in the env like MAIN_LOG_LEVEL for
+--log-level.
+
+The service manager then sets up the logger, opens the cache, starts the admin
+endpoint when --admin-addr is set and runs the services until they end or the
+process is interrupted, SIGHUP reloads the config and the log levels.`).
 	AddCommandRun(func(cmd *cobra.Command, args []string) {
-		service.RegisterService("main service", simulateWork)
+		service.RegisterServiceContext("main service", simulateWork)
 	}).
-	AddCommandFlag("log-dir", "", "log file").
-	AddCommandFlag("config", "", "config file").
-	AddCommandFlag("config-string", "", "config string").
-	AddCommandFlag("script", false, "script").
+	AddCommandFlag("log-dir", "", "log directory, the logs are written to stdout when not set").
+	AddCommandFlag("log-level", "", "log level: debug, info, warn or error").
+	AddCommandFlag("log-format", "", "log encoder: auto, console, json, logfmt or pretty").
+	AddCommandFlag("log-time-format", "", "log time format: iso8601, rfc3339, rfc3339nano, epoch, millis or a go layout").
+	AddCommandFlag("log-caller", false, "add the caller file and line to the log entries").
+	AddCommandFlag("log-stacktrace", false, "add a stack trace to the error log entries").
+	AddCommandFlag("log-per-service", false, "write the logs of each service to its own file in the log directory").
+	AddCommandFlag("instance", "", "instance name added to the log entries, the host name by default").
+	AddCommandFlag("cache-dir", "", "cache directory").
+	AddCommandFlag("cache-gc-interval", time.Duration(0), "interval of the cache garbage collection, 5m when not set").
+	AddCommandFlag("admin-addr", "", "admin endpoint address like localhost:8081, disabled when not set").
+	AddCommandFlagPersistent("config", "", "config file").
+	AddCommandFlagPersistent("config-string", "", "encoded config, used instead of a config file").
+	AddCommandFlag("script", false, "generate the script of the services").
+	AddCommandFlagHidden("script").
+	AddCommandFlagCategory("Log", "log-dir", "log-level", "log-format", "log-time-format", "log-caller", "log-stacktrace", "log-per-service").
+	AddCommandFlagCategory("Config", "config", "config-string").
+	AddCommandFlagCategory("Service", "instance", "cache-dir", "cache-gc-interval", "admin-addr").
+	AddCommandFlagEnum("log-format", logger.EncoderAuto, logger.EncoderConsole, logger.EncoderJSON, logger.EncoderLogfmt, logger.EncoderPretty).
+	AddCommandFlagValidator("log-level", validateLogLevel).
+	AddCommandFlagsMutuallyExclusive("config", "config-string").
 	Build()
 
 func Execute(version, commitHash, date string) {
@@ -32,20 +52,25 @@ func Execute(version, commitHash, date string) {
 	service.Execute(ctx, version, commitHash, date, rootCmd)
 }
 
-func simulateWork() error {
+func simulateWork(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-service.Context().Done():
-			return service.Context().Err()
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-ticker.C:
-			logger.Info(fmt.Sprintf("simulate work: uuid: %s, ulid: %s, time: %s",
-				service.GetRandomValue("ulid"),
-				service.GetRandomValue("uuid"),
-				time.Now().Format(time.RFC3339Nano),
-			))
+			service.Logger(ctx).Info("simulate work",
+				"uuid", service.GetRandomValue("uuid"),
+				"ulid", service.GetRandomValue("ulid"),
+			)
 		}
 	}
 }
+
+// validateLogLevel checks a log level flag
+func validateLogLevel(level string) error {
+	_, err := logger.ParseLevel(level)
+	return err
+}
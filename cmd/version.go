@@ -7,13 +7,9 @@ import (
 )
 
 var versionCmd = command.NewCommandBuilder("version").
-	AddCommandShortMessage("A brief description of your command").
-	AddCommandLongMessage(`A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This service is a tool to generate the needed files
-to quickly create a Cobra service.`).
+	AddCommandShortMessage("Print the version information").
+	AddCommandLongMessage(`Print the application version, commit hash, release date, go version and
+runtime information as json.`).
 	AddCommandRun(func(cmd *cobra.Command, args []string) {
 		service.RegisterService("version", versionCall)
 	}).
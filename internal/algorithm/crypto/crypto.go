@@ -6,9 +6,11 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/gob"
 	"encoding/hex"
+	"fmt"
 	"github.com/dyammarcano/application-manager/internal/algorithm/compression"
 	"github.com/dyammarcano/base58"
 	"io"
@@ -17,12 +19,16 @@ import (
 )
 
 const (
-	NonceSize    = 12
-	GenKeySize   = 12
-	KeysFileName = "keys.dat"
+	NonceSize      = 12
+	GenKeySize     = 12
+	StorageKeySize = 32
+	KeysFileName   = "keys.dat"
 )
 
-var keys map[int][]byte
+var (
+	ErrorStorageKeyNotFound = fmt.Errorf("crypto: storage key not found in keyring")
+	keys                    map[int][]byte
+)
 
 func init() {
 	home, err := os.UserHomeDir()
@@ -241,6 +247,18 @@ func DecryptPassword(message, password []byte) ([]byte, error) {
 	return decrypted, nil
 }
 
+// StorageKey derives the AES-256 key with the given id from the application keyring,
+// it is used to encrypt data at rest
+func StorageKey(id int) ([]byte, error) {
+	key, exist := keys[id]
+	if !exist {
+		return nil, fmt.Errorf("%w: id %d", ErrorStorageKeyNotFound, id)
+	}
+
+	sum := sha256.Sum256(key)
+	return sum[:StorageKeySize], nil
+}
+
 func GenerateKeys(keysPath string) error {
 	keys = make(map[int][]byte)
 
@@ -1,8 +1,10 @@
 package crypto
 
 import (
+	"bytes"
 	"github.com/dyammarcano/application-manager/internal/mock"
 	"github.com/stretchr/testify/assert"
+	"io"
 
 	"testing"
 )
@@ -26,3 +28,44 @@ func TestEncryptBytes(t *testing.T) {
 
 	assert.Equal(t, decrypted, []byte(mock.Message5kChars))
 }
+
+func TestStorageKey(t *testing.T) {
+	key, err := StorageKey(1)
+	assert.Nil(t, err)
+	assert.Len(t, key, StorageKeySize)
+
+	again, err := StorageKey(1)
+	assert.Nil(t, err)
+	assert.Equal(t, key, again)
+
+	_, err = StorageKey(-1)
+	assert.ErrorIs(t, err, ErrorStorageKeyNotFound)
+}
+
+func TestEncryptStream(t *testing.T) {
+	message := bytes.Repeat([]byte(mock.Message5kChars), 30)
+
+	for _, size := range []int{0, 10, StreamChunkSize, len(message)} {
+		var encrypted bytes.Buffer
+		w, err := AutoEncryptWriter(&encrypted)
+		assert.Nil(t, err)
+
+		_, err = w.Write(message[:size])
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+
+		r, err := AutoDecryptReader(bytes.NewReader(encrypted.Bytes()))
+		assert.Nil(t, err)
+
+		decrypted, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		assert.Equal(t, message[:size], append([]byte{}, decrypted...))
+
+		// a stream without its last chunk is truncated
+		r, err = AutoDecryptReader(bytes.NewReader(encrypted.Bytes()[:encrypted.Len()-1]))
+		assert.Nil(t, err)
+
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, ErrorStreamTruncated)
+	}
+}
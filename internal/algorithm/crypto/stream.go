@@ -0,0 +1,222 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// StreamChunkSize is the size of the plain text chunks sealed by the encrypt writer
+	StreamChunkSize = 64 * 1024
+
+	chunkLengthSize = 4
+	chunkLast       = 1
+)
+
+var (
+	ErrorStreamTruncated = fmt.Errorf("crypto: encrypted stream truncated")
+	ErrorStreamChunk     = fmt.Errorf("crypto: invalid encrypted stream chunk")
+)
+
+type (
+	// encryptWriter seals the written data in chunks of StreamChunkSize with AES-256-GCM
+	encryptWriter struct {
+		w       io.Writer
+		gcm     cipher.AEAD
+		nonce   []byte
+		counter uint64
+		buf     []byte
+		closed  bool
+	}
+
+	// decryptReader opens the chunks written by encryptWriter
+	decryptReader struct {
+		r       io.Reader
+		gcm     cipher.AEAD
+		nonce   []byte
+		counter uint64
+		buf     []byte
+		last    bool
+	}
+)
+
+// AutoEncryptWriter returns a writer encrypting the data written to it to w with AES-256-GCM and the
+// application keys, the data is sealed in chunks so it is never held whole in memory. Close writes the
+// last chunk, without it the stream is reported as truncated when it is read.
+func AutoEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	masterKey, err := generateKeys(GenKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, nonce, err := streamCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(masterKey); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:     w,
+		gcm:   gcm,
+		nonce: nonce,
+		buf:   make([]byte, 0, StreamChunkSize),
+	}, nil
+}
+
+// AutoDecryptReader returns a reader decrypting the stream written by AutoEncryptWriter from r
+func AutoDecryptReader(r io.Reader) (io.Reader, error) {
+	masterKey := make([]byte, GenKeySize)
+	if _, err := io.ReadFull(r, masterKey); err != nil {
+		return nil, ErrorStreamTruncated
+	}
+
+	gcm, nonce, err := streamCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:     r,
+		gcm:   gcm,
+		nonce: nonce,
+	}, nil
+}
+
+// streamCipher returns the cipher and the base nonce derived from the master key of a stream
+func streamCipher(masterKey []byte) (cipher.AEAD, []byte, error) {
+	key, nonce, err := extractKeys(masterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cc, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gcm, err := cipher.NewGCM(cc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcm, nonce, nil
+}
+
+// chunkNonce returns the nonce of the chunk counter, the base nonce with the counter xored in its last bytes
+func chunkNonce(base []byte, counter uint64) []byte {
+	nonce := append([]byte{}, base...)
+	offset := len(nonce) - 8
+
+	binary.BigEndian.PutUint64(nonce[offset:], binary.BigEndian.Uint64(nonce[offset:])^counter)
+	return nonce
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), StreamChunkSize-len(e.buf))
+		e.buf = append(e.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		// the full chunk is kept until more data arrives, the last chunk is written by Close
+		if len(e.buf) == StreamChunkSize && len(p) > 0 {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close writes the buffered data as the last chunk, it does not close the underlying writer
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+
+	e.closed = true
+	return e.seal(true)
+}
+
+// seal writes the buffered data as a chunk, its length followed by the sealed data
+func (e *encryptWriter) seal(last bool) error {
+	flag := []byte{0}
+	if last {
+		flag[0] = chunkLast
+	}
+
+	sealed := e.gcm.Seal(nil, chunkNonce(e.nonce, e.counter), e.buf, flag)
+	e.counter++
+	e.buf = e.buf[:0]
+
+	header := make([]byte, chunkLengthSize)
+	binary.BigEndian.PutUint32(header, uint32(len(sealed)))
+
+	if _, err := e.w.Write(header); err != nil {
+		return err
+	}
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.last {
+			return 0, io.EOF
+		}
+
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk
+func (d *decryptReader) open() error {
+	header := make([]byte, chunkLengthSize)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return ErrorStreamTruncated
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if size > StreamChunkSize+uint32(d.gcm.Overhead()) {
+		return ErrorStreamChunk
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return ErrorStreamTruncated
+	}
+
+	nonce := chunkNonce(d.nonce, d.counter)
+	d.counter++
+
+	// the chunk is sealed with its last flag, a chunk moved to the end does not open as the last one
+	if plain, err := d.gcm.Open(nil, nonce, sealed, []byte{0}); err == nil {
+		d.buf = plain
+		return nil
+	}
+
+	plain, err := d.gcm.Open(nil, nonce, sealed, []byte{chunkLast})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrorStreamChunk, err)
+	}
+
+	d.buf = plain
+	d.last = true
+	return nil
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/dyammarcano/application-manager/internal/algorithm/crypto"
+	"io"
+)
+
+const (
+	backupMagic          = "AMCB"
+	backupFlagCompressed = 1 << 0
+	backupFlagEncrypted  = 1 << 1
+	// backupFlagStreamed marks the encrypted backups written in chunks by crypto.AutoEncryptWriter, the
+	// backups without it were encrypted whole
+	backupFlagStreamed     = 1 << 2
+	restoreMaxPendingWrite = 256
+)
+
+var ErrorInvalidBackup = fmt.Errorf("cache: invalid backup header")
+
+type (
+	// BackupOption customizes Backup
+	BackupOption func(*backupConfig)
+
+	backupConfig struct {
+		flags byte
+	}
+)
+
+// WithCompression compresses the backup with the compression package
+func WithCompression() BackupOption {
+	return func(c *backupConfig) {
+		c.flags |= backupFlagCompressed
+	}
+}
+
+// WithEncryption encrypts the backup with the application keys of the crypto package
+func WithEncryption() BackupOption {
+	return func(c *backupConfig) {
+		c.flags |= backupFlagEncrypted | backupFlagStreamed
+	}
+}
+
+// Backup writes the entries with a version >= since to w, only the namespace keys are written for a view.
+// It returns the version of the last entry written, pass it + 1 as since to take an incremental backup.
+// The entries are compressed and encrypted as they are streamed, the backup is not held in memory.
+func (c *V3Cache) Backup(w io.Writer, since uint64, opts ...BackupOption) (uint64, error) {
+	cfg := &backupConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if _, err := w.Write(append([]byte(backupMagic), cfg.flags)); err != nil {
+		return 0, err
+	}
+
+	stream := c.db.NewStream()
+	stream.LogPrefix = "V3Cache.Backup"
+	if since > 0 {
+		// the stream skips versions <= SinceTs while Backup includes the since version
+		stream.SinceTs = since - 1
+	}
+	if c.prefix != "" {
+		stream.Prefix = []byte(c.prefix)
+	}
+
+	// the writers are closed in reverse order so each one flushes to the next
+	closers := make([]io.Closer, 0, 2)
+
+	if cfg.flags&backupFlagEncrypted != 0 {
+		ew, err := crypto.AutoEncryptWriter(w)
+		if err != nil {
+			return 0, err
+		}
+		closers = append(closers, ew)
+		w = ew
+	}
+
+	if cfg.flags&backupFlagCompressed != 0 {
+		gw := gzip.NewWriter(w)
+		closers = append(closers, gw)
+		w = gw
+	}
+
+	version, err := stream.Backup(w, since)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+// Restore loads a backup written by Backup, compression and encryption are detected from the backup header.
+// Incremental backups must be restored in the order they were taken, after the full backup.
+func (c *V3Cache) Restore(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(backupMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(backupMagic)]) != backupMagic {
+		return ErrorInvalidBackup
+	}
+
+	flags := header[len(backupMagic)]
+	var reader io.Reader = br
+
+	switch {
+	case flags&backupFlagStreamed != 0:
+		dr, err := crypto.AutoDecryptReader(reader)
+		if err != nil {
+			return err
+		}
+		reader = dr
+	case flags&backupFlagEncrypted != 0:
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		if data, err = crypto.AutoDecryptBytes(data); err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	if flags&backupFlagCompressed != 0 {
+		gr, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		reader = gr
+	}
+
+	return c.db.Load(reader, restoreMaxPendingWrite)
+}
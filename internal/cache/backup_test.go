@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"bytes"
+	"github.com/dyammarcano/application-manager/internal/algorithm/compression"
+	"github.com/dyammarcano/application-manager/internal/algorithm/crypto"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	src := newTestCache(t)
+
+	assert.Nil(t, src.Set("a", "1"))
+	assert.Nil(t, src.Set("b", "2"))
+
+	var full, incremental bytes.Buffer
+	version, err := src.Backup(&full, 0, WithCompression(), WithEncryption())
+	assert.Nil(t, err)
+
+	assert.Nil(t, src.Set("c", "3"))
+
+	_, err = src.Backup(&incremental, version+1)
+	assert.Nil(t, err)
+
+	dst := newTestCache(t)
+	assert.Nil(t, dst.Restore(&full))
+
+	length, err := dst.Length()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, length)
+
+	assert.Nil(t, dst.Restore(&incremental))
+
+	all, err := dst.GetAll()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, all)
+
+	assert.ErrorIs(t, dst.Restore(bytes.NewReader([]byte("nope"))), ErrorInvalidBackup)
+}
+
+func TestRestoreWholeEncryptedBackup(t *testing.T) {
+	src := newTestCache(t)
+	assert.Nil(t, src.Set("a", "1"))
+
+	var plain bytes.Buffer
+	_, err := src.Backup(&plain, 0)
+	assert.Nil(t, err)
+
+	// the backups encrypted whole, before they were streamed
+	data, err := compression.CompressData(plain.Bytes()[len(backupMagic)+1:])
+	assert.Nil(t, err)
+	data, err = crypto.AutoEncryptBytes(data)
+	assert.Nil(t, err)
+
+	dst := newTestCache(t)
+	assert.Nil(t, dst.Restore(bytes.NewReader(append([]byte(backupMagic+string(rune(backupFlagCompressed|backupFlagEncrypted))), data...))))
+
+	value, err := dst.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", value)
+}
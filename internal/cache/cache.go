@@ -1,95 +1,187 @@
 package cache
 
 import (
+	"errors"
+	"fmt"
 	"github.com/dgraph-io/badger/v3"
+	"strings"
 	"sync"
+	"time"
+)
+
+const (
+	// DefaultDir is the directory, relative to the current path, used when no cache directory is configured
+	DefaultDir = "cache"
+	// NamespaceSeparator separates the namespace name from the key
+	NamespaceSeparator = ":"
+)
+
+var (
+	ErrorInvalidNamespace = fmt.Errorf("cache: invalid namespace name")
+	ErrorKeyNotFound      = badger.ErrKeyNotFound
+	ErrorCacheInUse       = fmt.Errorf("cache: the cache directory is locked by another process, like the running service")
 )
 
 type (
 	processItem func(item *badger.Item) error
 
 	V3Cache struct {
-		db *badger.DB
-		wg sync.WaitGroup
+		db       *badger.DB
+		wg       sync.WaitGroup
+		prefix   string
+		counters *counters
 	}
 )
 
 // NewCache creates a new Badger database
-func NewCache(path string) (*V3Cache, error) {
-	db, err := badger.Open(badger.DefaultOptions(path))
+func NewCache(path string, opts ...Option) (*V3Cache, error) {
+	o := &options{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := badger.Open(o.badgerOptions(path))
 	if err != nil {
-		return nil, err
+		return nil, openError(err)
 	}
 
 	return &V3Cache{
-		db: db,
-		wg: sync.WaitGroup{},
+		db:       db,
+		wg:       sync.WaitGroup{},
+		counters: &counters{},
 	}, nil
 }
 
-// Close the Badger database
+// RunGC runs the Badger value log garbage collector until there is nothing left to rewrite,
+// the result is reported by Stats
+func (c *V3Cache) RunGC(discardRatio float64) error {
+	result := &GCResult{
+		Time: time.Now(),
+	}
+
+	err := c.runGC(discardRatio, result)
+	result.Duration = time.Since(result.Time)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	c.counters.setLastGC(result)
+	return err
+}
+
+func (c *V3Cache) runGC(discardRatio float64, result *GCResult) error {
+	for {
+		if err := c.db.RunValueLogGC(discardRatio); err != nil {
+			if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrRejected) {
+				return nil
+			}
+			return err
+		}
+		result.Rewritten++
+	}
+}
+
+// Namespace returns a view of the cache where all the keys are transparently prefixed with name,
+// the view shares the Badger database with its parent and can be nested
+func (c *V3Cache) Namespace(name string) (*V3Cache, error) {
+	if name == "" || strings.Contains(name, NamespaceSeparator) {
+		return nil, fmt.Errorf("%w: %q", ErrorInvalidNamespace, name)
+	}
+
+	return &V3Cache{
+		db:       c.db,
+		wg:       sync.WaitGroup{},
+		prefix:   c.prefix + name + NamespaceSeparator,
+		counters: c.counters,
+	}, nil
+}
+
+// Prefix returns the key prefix of the namespace, empty for the root cache
+func (c *V3Cache) Prefix() string {
+	return c.prefix
+}
+
+// Close the Badger database, closing a namespace view is a no-op
 func (c *V3Cache) Close() error {
+	if c.prefix != "" {
+		return nil
+	}
 	return c.db.Close()
 }
 
+// fullKey returns the key prefixed with the namespace
+func (c *V3Cache) fullKey(key string) []byte {
+	return []byte(c.prefix + key)
+}
+
+// trimKey removes the namespace prefix from a stored key
+func (c *V3Cache) trimKey(key []byte) string {
+	return strings.TrimPrefix(string(key), c.prefix)
+}
+
 // Get a value from the Badger database
 func (c *V3Cache) Get(key string) (string, error) {
-	var value string
+	value, err := c.GetBytes(key)
+	return string(value), err
+}
+
+// GetBytes a raw value from the Badger database
+func (c *V3Cache) GetBytes(key string) ([]byte, error) {
+	var value []byte
 	err := c.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
+		item, err := txn.Get(c.fullKey(key))
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			value = string(val)
-			return nil
-		})
+		value, err = item.ValueCopy(nil)
 		return err
 	})
+	c.counters.hit(err)
 	return value, err
 }
 
 // Set a value in the Badger database
 func (c *V3Cache) Set(key string, value string) error {
+	return c.SetBytes(key, []byte(value))
+}
+
+// SetBytes a raw value in the Badger database
+func (c *V3Cache) SetBytes(key string, value []byte) error {
 	return c.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), []byte(value))
+		return txn.SetEntry(newEntry(c.fullKey(key), value))
 	})
 }
 
 // Delete a value from the Badger database
 func (c *V3Cache) Delete(key string) error {
 	return c.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(key))
+		return txn.Delete(c.fullKey(key))
 	})
 }
 
-func (c *V3Cache) iterateDB(process processItem) error {
-	return c.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchSize = 10
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			if err := process(item); err != nil {
-				return err
-			}
-		}
-		return nil
+func (c *V3Cache) iterateDB(process processItem, opts ...ScanOption) error {
+	return c.iterate("", "", newScanConfig(opts), func(item *badger.Item) (bool, error) {
+		return true, process(item)
 	})
 }
 
+// DeleteAll removes all the keys of the cache, or only the keys of the namespace for a view
 func (c *V3Cache) DeleteAll() error {
+	if c.prefix != "" {
+		return c.db.DropPrefix([]byte(c.prefix))
+	}
 	return c.db.DropAll()
 }
 
 func (c *V3Cache) GetKeys() ([]string, error) {
 	keys := make([]string, 0)
 	err := c.iterateDB(func(item *badger.Item) error {
-		key := string(item.Key())
+		key := c.trimKey(item.Key())
 		keys = append(keys, key)
 		return nil
-	})
+	}, KeysOnly())
 	return keys, err
 }
 
@@ -98,12 +190,14 @@ func (c *V3Cache) Length() (int, error) {
 	err := c.iterateDB(func(item *badger.Item) error {
 		length++
 		return nil
-	})
+	}, KeysOnly())
 	return length, err
 }
 
-func (c *V3Cache) Size() int {
-	return len(c.db.Tables())
+// Size returns the size in bytes of the database on disk, LSM tree plus value log
+func (c *V3Cache) Size() int64 {
+	lsm, vlog := c.db.Size()
+	return lsm + vlog
 }
 
 func (c *V3Cache) GetAll() (map[string]string, error) {
@@ -114,7 +208,7 @@ func (c *V3Cache) GetAll() (map[string]string, error) {
 			value = string(val)
 			return nil
 		})
-		key := string(item.Key())
+		key := c.trimKey(item.Key())
 		values[key] = value
 
 		return err
@@ -0,0 +1,476 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/dyammarcano/application-manager/internal/algorithm/compression"
+	"github.com/dyammarcano/application-manager/internal/algorithm/crypto"
+	"io"
+	"math"
+	"reflect"
+)
+
+const (
+	tagNil byte = iota
+	tagBool
+	tagInt
+	tagUint
+	tagFloat
+	tagString
+	tagBytes
+	tagList
+	tagMap
+	tagStruct
+)
+
+var (
+	ErrorUnsupportedType = fmt.Errorf("cache: unsupported type for binary codec")
+	ErrorInvalidBinary   = fmt.Errorf("cache: invalid binary payload")
+
+	JSON   Codec = jsonCodec{}
+	Gob    Codec = gobCodec{}
+	Binary Codec = binaryCodec{}
+)
+
+type (
+	// Codec converts values to and from the bytes stored in the cache
+	Codec interface {
+		Name() string
+		Marshal(v any) ([]byte, error)
+		Unmarshal(data []byte, v any) error
+	}
+
+	jsonCodec struct{}
+
+	gobCodec struct{}
+
+	binaryCodec struct{}
+
+	compressedCodec struct {
+		codec Codec
+	}
+
+	encryptedCodec struct {
+		codec Codec
+	}
+)
+
+// Compressed wraps a codec so the encoded value is gzip compressed before being stored
+func Compressed(codec Codec) Codec {
+	return compressedCodec{codec: codec}
+}
+
+// Encrypted wraps a codec so the encoded value is encrypted with the application keys before being stored
+func Encrypted(codec Codec) Codec {
+	return encryptedCodec{codec: codec}
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (c compressedCodec) Name() string {
+	return c.codec.Name() + "+gzip"
+}
+
+func (c compressedCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return compression.CompressData(data)
+}
+
+func (c compressedCodec) Unmarshal(data []byte, v any) error {
+	dec, err := compression.DecompressData(data)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(dec, v)
+}
+
+func (c encryptedCodec) Name() string {
+	return c.codec.Name() + "+aes"
+}
+
+func (c encryptedCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.AutoEncryptBytes(data)
+}
+
+func (c encryptedCodec) Unmarshal(data []byte, v any) error {
+	dec, err := crypto.AutoDecryptBytes(data)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(dec, v)
+}
+
+func (binaryCodec) Name() string {
+	return "binary"
+}
+
+// Marshal encodes v using a compact, msgpack like, tagged binary format
+func (binaryCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeBinary(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data produced by Marshal into the value pointed by v
+func (binaryCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cache: unmarshal target must be a non nil pointer, got %T", v)
+	}
+
+	r := bytes.NewReader(data)
+	if err := decodeBinary(r, rv.Elem()); err != nil {
+		return err
+	}
+
+	if r.Len() != 0 {
+		return ErrorInvalidBinary
+	}
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, n uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], n)])
+}
+
+func encodeBinary(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(tagNil)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(tagNil)
+			return nil
+		}
+		return encodeBinary(buf, v.Elem())
+	case reflect.Bool:
+		buf.WriteByte(tagBool)
+		if v.Bool() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var tmp [binary.MaxVarintLen64]byte
+		buf.WriteByte(tagInt)
+		buf.Write(tmp[:binary.PutVarint(tmp[:], v.Int())])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteByte(tagUint)
+		writeUvarint(buf, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], math.Float64bits(v.Float()))
+		buf.WriteByte(tagFloat)
+		buf.Write(tmp[:])
+	case reflect.String:
+		buf.WriteByte(tagString)
+		writeUvarint(buf, uint64(v.Len()))
+		buf.WriteString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			buf.WriteByte(tagBytes)
+			writeUvarint(buf, uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				buf.WriteByte(byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+
+		buf.WriteByte(tagList)
+		writeUvarint(buf, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeBinary(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		buf.WriteByte(tagMap)
+		writeUvarint(buf, uint64(v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := encodeBinary(buf, iter.Key()); err != nil {
+				return err
+			}
+			if err := encodeBinary(buf, iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		buf.WriteByte(tagStruct)
+		writeUvarint(buf, uint64(t.NumField()))
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				buf.WriteByte(tagNil)
+				continue
+			}
+			if err := encodeBinary(buf, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnsupportedType, v.Type())
+	}
+
+	return nil
+}
+
+func readLength(r *bytes.Reader) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, ErrorInvalidBinary
+	}
+
+	if n > uint64(r.Len()) {
+		return 0, ErrorInvalidBinary
+	}
+	return int(n), nil
+}
+
+func decodeBinary(r *bytes.Reader, v reflect.Value) error {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return ErrorInvalidBinary
+	}
+
+	if tag == tagNil {
+		if v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if err := r.UnreadByte(); err != nil {
+			return err
+		}
+		return decodeBinary(r, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("%w: %s", ErrorUnsupportedType, v.Type())
+		}
+		if err := r.UnreadByte(); err != nil {
+			return err
+		}
+		value, err := decodeAny(r)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	switch tag {
+	case tagBool:
+		b, err := r.ReadByte()
+		if err != nil || v.Kind() != reflect.Bool {
+			return ErrorInvalidBinary
+		}
+		v.SetBool(b == 1)
+	case tagInt:
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			return ErrorInvalidBinary
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(n)
+		default:
+			return ErrorInvalidBinary
+		}
+	case tagUint:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return ErrorInvalidBinary
+		}
+		switch v.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			v.SetUint(n)
+		default:
+			return ErrorInvalidBinary
+		}
+	case tagFloat:
+		var tmp [8]byte
+		if _, err := io.ReadFull(r, tmp[:]); err != nil {
+			return ErrorInvalidBinary
+		}
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return ErrorInvalidBinary
+		}
+		v.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(tmp[:])))
+	case tagString:
+		n, err := readLength(r)
+		if err != nil || v.Kind() != reflect.String {
+			return ErrorInvalidBinary
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return ErrorInvalidBinary
+		}
+		v.SetString(string(data))
+	case tagBytes:
+		n, err := readLength(r)
+		if err != nil {
+			return err
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return ErrorInvalidBinary
+		}
+		switch {
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			reflect.Copy(v, reflect.ValueOf(data))
+		case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == n:
+			reflect.Copy(v, reflect.ValueOf(data))
+		default:
+			return ErrorInvalidBinary
+		}
+	case tagList:
+		n, err := readLength(r)
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		case reflect.Array:
+			if v.Len() != n {
+				return ErrorInvalidBinary
+			}
+		default:
+			return ErrorInvalidBinary
+		}
+		for i := 0; i < n; i++ {
+			if err := decodeBinary(r, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case tagMap:
+		n, err := readLength(r)
+		if err != nil || v.Kind() != reflect.Map {
+			return ErrorInvalidBinary
+		}
+		v.Set(reflect.MakeMapWithSize(v.Type(), n))
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := decodeBinary(r, key); err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeBinary(r, value); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	case tagStruct:
+		n, err := readLength(r)
+		if err != nil || v.Kind() != reflect.Struct || v.NumField() != n {
+			return ErrorInvalidBinary
+		}
+		for i := 0; i < n; i++ {
+			if !v.Type().Field(i).IsExported() {
+				if tag, err := r.ReadByte(); err != nil || tag != tagNil {
+					return ErrorInvalidBinary
+				}
+				continue
+			}
+			if err := decodeBinary(r, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrorInvalidBinary
+	}
+
+	return nil
+}
+
+// decodeAny decodes the next value into its natural go type, used when the target is an empty interface
+func decodeAny(r *bytes.Reader) (any, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, ErrorInvalidBinary
+	}
+	if err := r.UnreadByte(); err != nil {
+		return nil, err
+	}
+
+	var target reflect.Value
+	switch tag {
+	case tagNil:
+		_, _ = r.ReadByte()
+		return nil, nil
+	case tagBool:
+		target = reflect.New(reflect.TypeOf(false)).Elem()
+	case tagInt:
+		target = reflect.New(reflect.TypeOf(int64(0))).Elem()
+	case tagUint:
+		target = reflect.New(reflect.TypeOf(uint64(0))).Elem()
+	case tagFloat:
+		target = reflect.New(reflect.TypeOf(float64(0))).Elem()
+	case tagString:
+		target = reflect.New(reflect.TypeOf("")).Elem()
+	case tagBytes:
+		target = reflect.New(reflect.TypeOf([]byte(nil))).Elem()
+	case tagList:
+		target = reflect.New(reflect.TypeOf([]any(nil))).Elem()
+	case tagMap:
+		target = reflect.New(reflect.TypeOf(map[any]any(nil))).Elem()
+	default:
+		return nil, fmt.Errorf("%w: struct into interface", ErrorUnsupportedType)
+	}
+
+	if err := decodeBinary(r, target); err != nil {
+		return nil, err
+	}
+	return target.Interface(), nil
+}
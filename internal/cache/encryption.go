@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v3"
+	"strings"
+)
+
+var ErrorWrongEncryptionKey = fmt.Errorf("cache: the encryption key does not match the key the cache was encrypted with")
+
+// RotateKey re-encrypts the data keys of the closed cache in path with newKey, oldKey must be the current key.
+// An empty oldKey encrypts a plain cache, only the data written after the rotation is encrypted,
+// and an empty newKey turns encryption off for the new data.
+func RotateKey(path string, oldKey, newKey []byte) error {
+	opts := badger.KeyRegistryOptions{
+		Dir:                           path,
+		ReadOnly:                      true,
+		EncryptionKey:                 oldKey,
+		EncryptionKeyRotationDuration: dataKeyRotationDuration,
+	}
+
+	registry, err := badger.OpenKeyRegistry(opts)
+	if err != nil {
+		return openError(err)
+	}
+	defer registry.Close()
+
+	opts.EncryptionKey = newKey
+	return badger.WriteKeyRegistry(registry, opts)
+}
+
+// openError replaces the Badger key mismatch and directory lock errors with ones that explain what to check
+func openError(err error) error {
+	if errors.Is(err, badger.ErrEncryptionKeyMismatch) {
+		return ErrorWrongEncryptionKey
+	}
+
+	// badger does not export the lock error
+	if strings.Contains(err.Error(), "Cannot acquire directory lock") {
+		return fmt.Errorf("%w: %s", ErrorCacheInUse, err)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncryptionAtRest(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	c, err := NewCache(dir, EncryptionKey(oldKey))
+	assert.Nil(t, err)
+	assert.Nil(t, c.Set("token", "secret"))
+	assert.Nil(t, c.Close())
+
+	_, err = NewCache(dir, EncryptionKey(newKey))
+	assert.ErrorIs(t, err, ErrorWrongEncryptionKey)
+
+	_, err = NewCache(dir)
+	assert.ErrorIs(t, err, ErrorWrongEncryptionKey)
+
+	assert.ErrorIs(t, RotateKey(dir, newKey, oldKey), ErrorWrongEncryptionKey)
+	assert.Nil(t, RotateKey(dir, oldKey, newKey))
+
+	c, err = NewCache(dir, EncryptionKey(newKey))
+	assert.Nil(t, err)
+
+	value, err := c.Get("token")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", value)
+	assert.Nil(t, c.Close())
+}
+
+func TestKeyringKey(t *testing.T) {
+	c, err := NewCache(t.TempDir(), KeyringKey(7))
+	assert.Nil(t, err)
+	assert.Nil(t, c.Set("a", "1"))
+	assert.Nil(t, c.Close())
+
+	_, err = NewCache(t.TempDir(), KeyringKey(-1))
+	assert.NotNil(t, err)
+}
+
+func TestCacheInUse(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := NewCache(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Set("a", "1"))
+
+	// the writer locks the directory, read-only handles included
+	_, err = NewCache(dir, ReadOnly())
+	assert.ErrorIs(t, err, ErrorCacheInUse)
+	assert.Nil(t, c.Close())
+
+	reader, err := NewCache(dir, ReadOnly())
+	assert.Nil(t, err)
+	assert.Nil(t, reader.Close())
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+type (
+	// MemoryStore is an in-memory LRU Store, the least recently used keys are evicted
+	// once the capacity is reached
+	MemoryStore struct {
+		mutex     sync.Mutex
+		capacity  int
+		items     map[string]*list.Element
+		order     *list.List
+		hits      uint64
+		misses    uint64
+		evictions uint64
+	}
+
+	memoryItem struct {
+		key   string
+		value []byte
+	}
+)
+
+// NewMemoryStore creates an in-memory LRU store, a capacity <= 0 means unbounded
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get a value from the store
+func (m *MemoryStore) Get(key string) (string, error) {
+	value, err := m.GetBytes(key)
+	return string(value), err
+}
+
+// GetBytes a raw value from the store
+func (m *MemoryStore) GetBytes(key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	elem, exist := m.items[key]
+	if !exist {
+		m.misses++
+		return nil, ErrorKeyNotFound
+	}
+
+	m.hits++
+	m.order.MoveToFront(elem)
+	return append([]byte{}, elem.Value.(*memoryItem).value...), nil
+}
+
+// Set a value in the store
+func (m *MemoryStore) Set(key string, value string) error {
+	return m.SetBytes(key, []byte(value))
+}
+
+// SetBytes a raw value in the store
+func (m *MemoryStore) SetBytes(key string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	value = append([]byte{}, value...)
+
+	if elem, exist := m.items[key]; exist {
+		elem.Value.(*memoryItem).value = value
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, value: value})
+
+	if m.capacity > 0 && m.order.Len() > m.capacity {
+		m.removeElement(m.order.Back())
+		m.evictions++
+	}
+	return nil
+}
+
+// Delete a value from the store
+func (m *MemoryStore) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if elem, exist := m.items[key]; exist {
+		m.removeElement(elem)
+	}
+	return nil
+}
+
+// DeleteAll removes all the keys of the store
+func (m *MemoryStore) DeleteAll() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.items = make(map[string]*list.Element)
+	m.order.Init()
+	return nil
+}
+
+// GetKeys returns the keys of the store in ascending order
+func (m *MemoryStore) GetKeys() ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keys := make([]string, 0, len(m.items))
+	for key := range m.items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetAll returns all the values of the store
+func (m *MemoryStore) GetAll() (map[string]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	values := make(map[string]string, len(m.items))
+	for key, elem := range m.items {
+		values[key] = string(elem.Value.(*memoryItem).value)
+	}
+	return values, nil
+}
+
+// Length returns the number of keys in the store
+func (m *MemoryStore) Length() (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.items), nil
+}
+
+// Close releases the memory of the store
+func (m *MemoryStore) Close() error {
+	return m.DeleteAll()
+}
+
+func (m *MemoryStore) removeElement(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.items, elem.Value.(*memoryItem).key)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	c := newTestCache(t)
+
+	users, err := c.Namespace("users")
+	assert.Nil(t, err)
+
+	orders, err := c.Namespace("orders")
+	assert.Nil(t, err)
+
+	assert.Nil(t, users.Set("1", "alice"))
+	assert.Nil(t, users.Set("2", "bob"))
+	assert.Nil(t, orders.Set("1", "book"))
+
+	value, err := users.Get("1")
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", value)
+
+	value, err = c.Get("orders:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "book", value)
+
+	keys, err := users.GetKeys()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"1", "2"}, keys)
+
+	length, err := orders.Length()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, length)
+
+	all, err := users.GetAll()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"1": "alice", "2": "bob"}, all)
+
+	assert.Nil(t, users.DeleteAll())
+
+	length, err = users.Length()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, length)
+
+	length, err = orders.Length()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, length)
+
+	_, err = c.Namespace("in:valid")
+	assert.ErrorIs(t, err, ErrorInvalidNamespace)
+}
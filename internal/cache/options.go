@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dyammarcano/application-manager/internal/algorithm/crypto"
+	"time"
+)
+
+const (
+	encryptionIndexCacheSize = 100 << 20
+	dataKeyRotationDuration  = 10 * 24 * time.Hour
+)
+
+type (
+	// Option customizes how NewCache opens the Badger database
+	Option func(*options) error
+
+	options struct {
+		readOnly      bool
+		encryptionKey []byte
+	}
+)
+
+// ReadOnly opens the database in read-only mode, writes fail. Read-only handles can share the directory with
+// each other but not with a writer, which locks it, so the cache of a running service cannot be opened
+func ReadOnly() Option {
+	return func(o *options) error {
+		o.readOnly = true
+		return nil
+	}
+}
+
+// EncryptionKey enables encryption at rest with an AES key of 16, 24 or 32 bytes
+func EncryptionKey(key []byte) Option {
+	return func(o *options) error {
+		o.encryptionKey = key
+		return nil
+	}
+}
+
+// KeyringKey enables encryption at rest with the storage key id of the application keyring
+func KeyringKey(id int) Option {
+	return func(o *options) error {
+		key, err := crypto.StorageKey(id)
+		if err != nil {
+			return err
+		}
+
+		o.encryptionKey = key
+		return nil
+	}
+}
+
+// badgerOptions returns the Badger options for the database in path
+func (o *options) badgerOptions(path string) badger.Options {
+	opts := badger.DefaultOptions(path).
+		WithReadOnly(o.readOnly)
+
+	if len(o.encryptionKey) > 0 {
+		// Badger requires an index cache when the tables are encrypted
+		opts = opts.WithEncryptionKey(o.encryptionKey).
+			WithEncryptionKeyRotationDuration(dataKeyRotationDuration).
+			WithIndexCacheSize(encryptionIndexCacheSize)
+	}
+	return opts
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/dgraph-io/badger/v3"
+)
+
+const defaultPrefetchSize = 100
+
+var ErrorInvalidCursor = fmt.Errorf("cache: invalid cursor")
+
+type (
+	// IterateFunc is called for every entry visited by Iterate, returning false stops the iteration
+	IterateFunc func(key, value string) bool
+
+	// ScanOption customizes Scan and Iterate
+	ScanOption func(*scanConfig)
+
+	// Entry is a key/value pair returned by Scan, Value is empty in key only mode
+	Entry struct {
+		Key   string `json:"key"`
+		Value string `json:"value,omitempty"`
+	}
+
+	// Page is a page of entries returned by Scan, Cursor is empty when there are no more entries
+	Page struct {
+		Entries []Entry `json:"entries"`
+		Cursor  string  `json:"cursor,omitempty"`
+	}
+
+	scanConfig struct {
+		keysOnly     bool
+		reverse      bool
+		prefetchSize int
+	}
+
+	// cursor is the state serialized in the opaque cursor token
+	cursor struct {
+		Prefix     string `json:"p,omitempty"`
+		StartAfter string `json:"a"`
+		Reverse    bool   `json:"r,omitempty"`
+		KeysOnly   bool   `json:"k,omitempty"`
+	}
+)
+
+// KeysOnly skips fetching the values, only the keys are visited
+func KeysOnly() ScanOption {
+	return func(c *scanConfig) {
+		c.keysOnly = true
+	}
+}
+
+// Reverse visits the keys in descending order
+func Reverse() ScanOption {
+	return func(c *scanConfig) {
+		c.reverse = true
+	}
+}
+
+// PrefetchSize sets how many values are fetched ahead while iterating
+func PrefetchSize(size int) ScanOption {
+	return func(c *scanConfig) {
+		if size > 0 {
+			c.prefetchSize = size
+		}
+	}
+}
+
+func newScanConfig(opts []ScanOption) *scanConfig {
+	cfg := &scanConfig{
+		prefetchSize: defaultPrefetchSize,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Iterate visits the entries whose key starts with prefix until fn returns false or ctx is done
+func (c *V3Cache) Iterate(ctx context.Context, prefix string, fn IterateFunc, opts ...ScanOption) error {
+	cfg := newScanConfig(opts)
+
+	return c.iterate(prefix, "", cfg, func(item *badger.Item) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		key := c.trimKey(item.Key())
+		if cfg.keysOnly {
+			return fn(key, ""), nil
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return false, err
+		}
+		return fn(key, string(value)), nil
+	})
+}
+
+// Scan returns up to limit entries whose key starts with prefix and sort after startAfter,
+// the returned cursor can be passed to ScanCursor to fetch the next page
+func (c *V3Cache) Scan(prefix, startAfter string, limit int, opts ...ScanOption) (*Page, error) {
+	cfg := newScanConfig(opts)
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("cache: invalid scan limit %d", limit)
+	}
+
+	page := &Page{
+		Entries: make([]Entry, 0, limit),
+	}
+
+	more := false
+	err := c.iterate(prefix, startAfter, cfg, func(item *badger.Item) (bool, error) {
+		if len(page.Entries) == limit {
+			more = true
+			return false, nil
+		}
+
+		entry := Entry{
+			Key: c.trimKey(item.Key()),
+		}
+
+		if !cfg.keysOnly {
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return false, err
+			}
+			entry.Value = string(value)
+		}
+
+		page.Entries = append(page.Entries, entry)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if more {
+		page.Cursor = encodeCursor(cursor{
+			Prefix:     prefix,
+			StartAfter: page.Entries[len(page.Entries)-1].Key,
+			Reverse:    cfg.reverse,
+			KeysOnly:   cfg.keysOnly,
+		})
+	}
+
+	return page, nil
+}
+
+// ScanCursor returns the page following the one that produced token
+func (c *V3Cache) ScanCursor(token string, limit int) (*Page, error) {
+	cur, err := decodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]ScanOption, 0, 2)
+	if cur.Reverse {
+		opts = append(opts, Reverse())
+	}
+
+	if cur.KeysOnly {
+		opts = append(opts, KeysOnly())
+	}
+
+	return c.Scan(cur.Prefix, cur.StartAfter, limit, opts...)
+}
+
+// iterate walks the keys starting with prefix, skipping up to and including startAfter,
+// process returns false to stop the iteration
+func (c *V3Cache) iterate(prefix, startAfter string, cfg *scanConfig, process func(item *badger.Item) (bool, error)) error {
+	return c.db.View(func(txn *badger.Txn) error {
+		fullPrefix := c.fullKey(prefix)
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = fullPrefix
+		opts.Reverse = cfg.reverse
+		opts.PrefetchValues = !cfg.keysOnly
+		opts.PrefetchSize = cfg.prefetchSize
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		seek := fullPrefix
+		if cfg.reverse {
+			seek = append(append([]byte{}, fullPrefix...), 0xFF)
+		}
+
+		var after []byte
+		if startAfter != "" {
+			after = c.fullKey(startAfter)
+			if (!cfg.reverse && string(after) > string(seek)) || (cfg.reverse && string(after) < string(seek)) {
+				seek = after
+			}
+		}
+
+		for it.Seek(seek); it.Valid(); it.Next() {
+			item := it.Item()
+			if after != nil && string(item.Key()) == string(after) {
+				continue
+			}
+
+			next, err := process(item)
+			if err != nil {
+				return err
+			}
+
+			if !next {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func encodeCursor(cur cursor) string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidCursor, err)
+	}
+
+	cur := &cursor{}
+	if err := json.Unmarshal(data, cur); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidCursor, err)
+	}
+	return cur, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScanPagination(t *testing.T) {
+	c := newTestCache(t)
+
+	for i := 0; i < 25; i++ {
+		assert.Nil(t, c.Set(fmt.Sprintf("item:%02d", i), fmt.Sprint(i)))
+	}
+	assert.Nil(t, c.Set("other", "x"))
+
+	keys := make([]string, 0)
+	page, err := c.Scan("item:", "", 10)
+	assert.Nil(t, err)
+
+	for {
+		for _, entry := range page.Entries {
+			keys = append(keys, entry.Key)
+		}
+
+		if page.Cursor == "" {
+			break
+		}
+
+		page, err = c.ScanCursor(page.Cursor, 10)
+		assert.Nil(t, err)
+	}
+
+	assert.Len(t, keys, 25)
+	assert.Equal(t, "item:00", keys[0])
+	assert.Equal(t, "item:24", keys[24])
+
+	page, err = c.Scan("item:", "item:20", 2, Reverse(), KeysOnly())
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{{Key: "item:19"}, {Key: "item:18"}}, page.Entries)
+	assert.NotEmpty(t, page.Cursor)
+
+	_, err = c.ScanCursor("!", 1)
+	assert.ErrorIs(t, err, ErrorInvalidCursor)
+}
+
+func TestIterate(t *testing.T) {
+	c := newTestCache(t)
+	ns, err := c.Namespace("ns")
+	assert.Nil(t, err)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, ns.Set(fmt.Sprint(i), "v"))
+	}
+
+	visited := make([]string, 0)
+	err = ns.Iterate(context.Background(), "", func(key, value string) bool {
+		visited = append(visited, key+"="+value)
+		return len(visited) < 3
+	}, Reverse())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4=v", "3=v", "2=v"}, visited)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = ns.Iterate(ctx, "", func(key, value string) bool { return true })
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Stats reports the size and usage of a cache, Keys and Evictions are only counted by the memory store,
+	// TableKeys and BlockCacheEvictions by the Badger cache
+	Stats struct {
+		LSMSize             int64     `json:"lsm_size"`
+		VLogSize            int64     `json:"vlog_size"`
+		Tables              int       `json:"tables"`
+		TableKeys           uint64    `json:"table_keys,omitempty"`
+		Keys                uint64    `json:"keys,omitempty"`
+		Hits                uint64    `json:"hits"`
+		Misses              uint64    `json:"misses"`
+		Evictions           uint64    `json:"evictions,omitempty"`
+		BlockCacheEvictions uint64    `json:"block_cache_evictions,omitempty"`
+		LastGC              *GCResult `json:"last_gc,omitempty"`
+	}
+
+	// GCResult is the outcome of the last value log garbage collection
+	GCResult struct {
+		Time      time.Time     `json:"time"`
+		Duration  time.Duration `json:"duration"`
+		Rewritten int           `json:"rewritten"`
+		Error     string        `json:"error,omitempty"`
+	}
+
+	// counters are shared by a cache and its namespace views
+	counters struct {
+		hits   atomic.Uint64
+		misses atomic.Uint64
+		mutex  sync.Mutex
+		lastGC *GCResult
+	}
+)
+
+// hit records the outcome of a read
+func (c *counters) hit(err error) {
+	switch {
+	case err == nil:
+		c.hits.Add(1)
+	case errors.Is(err, ErrorKeyNotFound):
+		c.misses.Add(1)
+	}
+}
+
+func (c *counters) setLastGC(result *GCResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lastGC = result
+}
+
+func (c *counters) getLastGC() *GCResult {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.lastGC == nil {
+		return nil
+	}
+
+	result := *c.lastGC
+	return &result
+}
+
+// Stats returns the statistics of the database, they are shared by all the namespaces. TableKeys is the
+// number of keys written to the tables, it misses the writes still in the memtable and includes the old
+// versions and the deletes not yet compacted, use Length for the live keys. BlockCacheEvictions are the
+// blocks evicted from the Badger block cache, the entries themselves are never evicted.
+func (c *V3Cache) Stats() *Stats {
+	lsm, vlog := c.db.Size()
+	tables := c.db.Tables()
+
+	stats := &Stats{
+		LSMSize:  lsm,
+		VLogSize: vlog,
+		Tables:   len(tables),
+		Hits:     c.counters.hits.Load(),
+		Misses:   c.counters.misses.Load(),
+		LastGC:   c.counters.getLastGC(),
+	}
+
+	for _, table := range tables {
+		stats.TableKeys += uint64(table.KeyCount)
+	}
+
+	if metrics := c.db.BlockCacheMetrics(); metrics != nil {
+		stats.BlockCacheEvictions = metrics.KeysEvicted()
+	}
+
+	return stats
+}
+
+// Stats returns the statistics of the memory store, Keys are the stored keys and Evictions the keys
+// evicted to stay within the capacity
+func (m *MemoryStore) Stats() *Stats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return &Stats{
+		Keys:      uint64(len(m.items)),
+		Hits:      m.hits,
+		Misses:    m.misses,
+		Evictions: m.evictions,
+	}
+}
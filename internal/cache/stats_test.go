@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	c := newTestCache(t)
+	ns, err := c.Namespace("ns")
+	assert.Nil(t, err)
+
+	assert.Nil(t, ns.Set("a", "1"))
+
+	_, err = ns.Get("a")
+	assert.Nil(t, err)
+
+	_, err = c.Get("missing")
+	assert.ErrorIs(t, err, ErrorKeyNotFound)
+
+	assert.Nil(t, c.RunGC(0.5))
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(0), stats.Keys, "the live keys are counted by Length")
+	assert.Equal(t, uint64(0), stats.Evictions, "the entries are never evicted")
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.NotNil(t, stats.LastGC)
+	assert.Empty(t, stats.LastGC.Error)
+
+	m := NewMemoryStore(1)
+	assert.Nil(t, m.Set("a", "1"))
+	assert.Nil(t, m.Set("b", "2"))
+
+	_, err = m.Get("a")
+	assert.ErrorIs(t, err, ErrorKeyNotFound)
+
+	stats = m.Stats()
+	assert.Equal(t, uint64(1), stats.Keys)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
@@ -0,0 +1,23 @@
+package cache
+
+var (
+	_ Store = (*V3Cache)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*TieredStore)(nil)
+)
+
+type (
+	// Store is the common interface of the cache backends
+	Store interface {
+		Get(key string) (string, error)
+		GetBytes(key string) ([]byte, error)
+		Set(key string, value string) error
+		SetBytes(key string, value []byte) error
+		Delete(key string) error
+		DeleteAll() error
+		GetKeys() ([]string, error)
+		GetAll() (map[string]string, error)
+		Length() (int, error)
+		Close() error
+	}
+)
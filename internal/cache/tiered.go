@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"errors"
+	"golang.org/x/sync/singleflight"
+	"sync"
+)
+
+type (
+	// Loader loads the value of a key missing in every tier, returning ErrorKeyNotFound when it does not exist
+	Loader func(key string) ([]byte, error)
+
+	// TieredStore is a read-through Store with a fast L1 in front of a durable L2, L2 is the source of truth
+	TieredStore struct {
+		l1     Store
+		l2     Store
+		loader Loader
+		group  singleflight.Group
+		mutex  sync.Mutex
+		// reads are the keys being read through, true once the key is written so the value read before
+		// the write does not fill the tiers
+		reads map[string]bool
+		// writes counts the writes of each key in progress, clears the DeleteAll in progress
+		writes map[string]int
+		clears int
+	}
+)
+
+// NewTieredStore creates a tiered store, loader is optional and is called when a key misses both tiers,
+// concurrent misses of the same key are de-duplicated so the loader runs once
+func NewTieredStore(l1, l2 Store, loader Loader) *TieredStore {
+	return &TieredStore{
+		l1:     l1,
+		l2:     l2,
+		loader: loader,
+		reads:  make(map[string]bool),
+		writes: make(map[string]int),
+	}
+}
+
+// Get a value from the store
+func (s *TieredStore) Get(key string) (string, error) {
+	value, err := s.GetBytes(key)
+	return string(value), err
+}
+
+// GetBytes a raw value from L1, falling back to L2 and the loader
+func (s *TieredStore) GetBytes(key string) ([]byte, error) {
+	if value, err := s.l1.GetBytes(key); err == nil {
+		return value, nil
+	} else if !errors.Is(err, ErrorKeyNotFound) {
+		return nil, err
+	}
+
+	value, err, _ := s.group.Do(key, func() (any, error) {
+		return s.readThrough(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, value.([]byte)...), nil
+}
+
+// readThrough reads a key missing in L1 from L2 or the loader, populating the tiers unless the key is
+// written meanwhile
+func (s *TieredStore) readThrough(key string) ([]byte, error) {
+	s.mutex.Lock()
+	s.reads[key] = false
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.reads, key)
+		s.mutex.Unlock()
+	}()
+
+	value, err := s.l2.GetBytes(key)
+	if err == nil {
+		return value, s.fill(key, value, false)
+	}
+
+	if !errors.Is(err, ErrorKeyNotFound) || s.loader == nil {
+		return nil, err
+	}
+
+	value, err = s.loader(key)
+	if err != nil {
+		return nil, err
+	}
+	return value, s.fill(key, value, true)
+}
+
+// fill stores the value read through in L1, and in L2 when it was loaded, the tiers are not filled when
+// the key is written during the read since they hold or will hold the newer value
+func (s *TieredStore) fill(key string, value []byte, loaded bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reads[key] || s.writes[key] > 0 || s.clears > 0 {
+		return nil
+	}
+
+	if loaded {
+		if err := s.l2.SetBytes(key, value); err != nil {
+			return err
+		}
+	}
+	return s.l1.SetBytes(key, value)
+}
+
+// invalidate marks the read through of key as stale, all of them when all is set, the mutex must be held
+func (s *TieredStore) invalidate(key string, all bool) {
+	for read := range s.reads {
+		if all || read == key {
+			s.reads[read] = true
+		}
+	}
+}
+
+// write applies a write of key, or of all the keys, to both tiers, the reads through of the key started
+// before the L1 write do not fill the tiers afterwards
+func (s *TieredStore) write(key string, all bool, l2, l1 func() error) error {
+	s.mutex.Lock()
+	if all {
+		s.clears++
+	} else {
+		s.writes[key]++
+	}
+	s.mutex.Unlock()
+
+	err := l2()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if all {
+		s.clears--
+	} else if s.writes[key]--; s.writes[key] == 0 {
+		delete(s.writes, key)
+	}
+
+	// a read through in progress may have read the previous value, even when the write failed
+	s.invalidate(key, all)
+	if err != nil {
+		return err
+	}
+	return l1()
+}
+
+// Set a value in the store
+func (s *TieredStore) Set(key string, value string) error {
+	return s.SetBytes(key, []byte(value))
+}
+
+// SetBytes a raw value in both tiers
+func (s *TieredStore) SetBytes(key string, value []byte) error {
+	return s.write(key, false, func() error {
+		return s.l2.SetBytes(key, value)
+	}, func() error {
+		return s.l1.SetBytes(key, value)
+	})
+}
+
+// Delete a value from both tiers
+func (s *TieredStore) Delete(key string) error {
+	return s.write(key, false, func() error {
+		return s.l2.Delete(key)
+	}, func() error {
+		return s.l1.Delete(key)
+	})
+}
+
+// DeleteAll removes all the keys of both tiers
+func (s *TieredStore) DeleteAll() error {
+	return s.write("", true, s.l2.DeleteAll, s.l1.DeleteAll)
+}
+
+// GetKeys returns the keys stored in L2
+func (s *TieredStore) GetKeys() ([]string, error) {
+	return s.l2.GetKeys()
+}
+
+// GetAll returns the values stored in L2
+func (s *TieredStore) GetAll() (map[string]string, error) {
+	return s.l2.GetAll()
+}
+
+// Length returns the number of keys stored in L2
+func (s *TieredStore) Length() (int, error) {
+	return s.l2.Length()
+}
+
+// Close both tiers
+func (s *TieredStore) Close() error {
+	return errors.Join(s.l1.Close(), s.l2.Close())
+}
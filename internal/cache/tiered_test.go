@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEviction(t *testing.T) {
+	m := NewMemoryStore(2)
+
+	assert.Nil(t, m.Set("a", "1"))
+	assert.Nil(t, m.Set("b", "2"))
+
+	_, err := m.Get("a")
+	assert.Nil(t, err)
+
+	assert.Nil(t, m.Set("c", "3"))
+
+	_, err = m.Get("b")
+	assert.ErrorIs(t, err, ErrorKeyNotFound)
+
+	keys, err := m.GetKeys()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "c"}, keys)
+}
+
+func TestTieredStoreReadThrough(t *testing.T) {
+	var calls atomic.Int32
+
+	l2 := newTestCache(t)
+	store := NewTieredStore(NewMemoryStore(10), l2, func(key string) ([]byte, error) {
+		calls.Add(1)
+		<-time.After(50 * time.Millisecond)
+		return []byte("loaded " + key), nil
+	})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			value, err := store.Get("key")
+			assert.Nil(t, err)
+			assert.Equal(t, "loaded key", value)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	value, err := l2.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "loaded key", value)
+
+	typed := NewTyped[string, int](store, Binary)
+	assert.Nil(t, typed.Set("n", 7))
+
+	n, err := NewTyped[string, int](l2, Binary).Get("n")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, n)
+}
+
+// pausedStore pauses the reads after they reach the wrapped store until release is closed
+type pausedStore struct {
+	Store
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (s *pausedStore) GetBytes(key string) ([]byte, error) {
+	value, err := s.Store.GetBytes(key)
+	s.read <- struct{}{}
+	<-s.release
+	return value, err
+}
+
+func TestTieredStoreWriteDuringRead(t *testing.T) {
+	l2 := newTestCache(t)
+	assert.Nil(t, l2.Set("key", "old"))
+
+	paused := &pausedStore{Store: l2, read: make(chan struct{}), release: make(chan struct{})}
+	store := NewTieredStore(NewMemoryStore(10), paused, nil)
+
+	done := make(chan string)
+	go func() {
+		value, err := store.Get("key")
+		assert.Nil(t, err)
+		done <- value
+	}()
+
+	<-paused.read
+	assert.Nil(t, store.Set("key", "new"))
+	close(paused.release)
+	assert.Equal(t, "old", <-done)
+
+	// the value read before the write does not fill L1
+	value, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "new", value)
+}
+
+func TestTieredStoreWriteDuringLoad(t *testing.T) {
+	loading := make(chan struct{})
+	release := make(chan struct{})
+
+	l2 := newTestCache(t)
+	store := NewTieredStore(NewMemoryStore(10), l2, func(key string) ([]byte, error) {
+		loading <- struct{}{}
+		<-release
+		return []byte("loaded"), nil
+	})
+
+	done := make(chan string)
+	go func() {
+		value, err := store.Get("key")
+		assert.Nil(t, err)
+		done <- value
+	}()
+
+	<-loading
+	assert.Nil(t, store.Set("key", "new"))
+	close(release)
+	assert.Equal(t, "loaded", <-done)
+
+	// the loaded value does not replace the one written meanwhile
+	for _, s := range []Store{store, l2} {
+		value, err := s.Get("key")
+		assert.Nil(t, err)
+		assert.Equal(t, "new", value)
+	}
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v3"
+	"math/rand"
+	"time"
+)
+
+const (
+	maxTxnRetries   = 32
+	maxTxnRetryWait = 50 * time.Millisecond
+)
+
+var ErrorTxnConflict = fmt.Errorf("cache: transaction conflict after %d retries", maxTxnRetries)
+
+type (
+	// Tx is a read-write transaction over the cache, keys are scoped to the cache namespace
+	Tx interface {
+		Get(key string) (string, error)
+		Set(key, value string) error
+		Delete(key string) error
+		// CompareAndSwap sets key to value when its current value is old, a missing key matches an empty old
+		CompareAndSwap(key, old, value string) (bool, error)
+	}
+
+	// Batch accumulates writes and applies them with a single Badger WriteBatch, it is not atomic
+	// for very large loads but it is much faster than one transaction per key
+	Batch struct {
+		cache *V3Cache
+		wb    *badger.WriteBatch
+	}
+
+	txn struct {
+		cache *V3Cache
+		txn   *badger.Txn
+	}
+)
+
+// Txn runs fn in a read-write transaction, fn is retried when the commit fails with a conflict,
+// so it must not have side effects other than the ones applied through tx
+func (c *V3Cache) Txn(fn func(tx Tx) error) error {
+	wait := time.Millisecond
+
+	for i := 0; i < maxTxnRetries; i++ {
+		err := c.db.Update(func(t *badger.Txn) error {
+			return fn(&txn{cache: c, txn: t})
+		})
+
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+
+		// back off with jitter so concurrent writers of the same keys do not collide again
+		<-time.After(time.Duration(rand.Int63n(int64(wait))) + time.Microsecond)
+		wait = min(wait*2, maxTxnRetryWait)
+	}
+	return ErrorTxnConflict
+}
+
+// Batch creates a new write batch, Flush must be called to apply the writes or Cancel to discard them
+func (c *V3Cache) Batch() *Batch {
+	return &Batch{
+		cache: c,
+		wb:    c.db.NewWriteBatch(),
+	}
+}
+
+// Set adds a value to the batch
+func (b *Batch) Set(key, value string) error {
+	return b.SetBytes(key, []byte(value))
+}
+
+// SetBytes adds a raw value to the batch
+func (b *Batch) SetBytes(key string, value []byte) error {
+	return b.wb.SetEntry(newEntry(b.cache.fullKey(key), value))
+}
+
+// Delete adds a delete to the batch
+func (b *Batch) Delete(key string) error {
+	return b.wb.Delete(b.cache.fullKey(key))
+}
+
+// Flush waits until all the writes of the batch are applied
+func (b *Batch) Flush() error {
+	return b.wb.Flush()
+}
+
+// Cancel discards the pending writes of the batch
+func (b *Batch) Cancel() {
+	b.wb.Cancel()
+}
+
+func (t *txn) Get(key string) (string, error) {
+	item, err := t.txn.Get(t.cache.fullKey(key))
+	if err != nil {
+		return "", err
+	}
+
+	value, err := item.ValueCopy(nil)
+	return string(value), err
+}
+
+func (t *txn) Set(key, value string) error {
+	return t.txn.SetEntry(newEntry(t.cache.fullKey(key), []byte(value)))
+}
+
+func (t *txn) Delete(key string) error {
+	return t.txn.Delete(t.cache.fullKey(key))
+}
+
+func (t *txn) CompareAndSwap(key, old, value string) (bool, error) {
+	current, err := t.Get(key)
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return false, err
+	}
+
+	if current != old {
+		return false, nil
+	}
+	return true, t.Set(key, value)
+}
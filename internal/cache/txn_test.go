@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	c := newTestCache(t)
+
+	batch := c.Batch()
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, batch.Set(fmt.Sprint(i), "v"))
+	}
+	assert.Nil(t, batch.Flush())
+
+	length, err := c.Length()
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, length)
+}
+
+func TestTxnCounter(t *testing.T) {
+	c := newTestCache(t)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := c.Txn(func(tx Tx) error {
+				value, err := tx.Get("counter")
+				if err != nil && !errors.Is(err, ErrorKeyNotFound) {
+					return err
+				}
+
+				n, _ := strconv.Atoi(value)
+				return tx.Set("counter", strconv.Itoa(n+1))
+			})
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	value, err := c.Get("counter")
+	assert.Nil(t, err)
+	assert.Equal(t, "20", value)
+
+	err = c.Txn(func(tx Tx) error {
+		swapped, err := tx.CompareAndSwap("counter", "19", "0")
+		assert.False(t, swapped)
+		return err
+	})
+	assert.Nil(t, err)
+}
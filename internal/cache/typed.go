@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type (
+	// Key are the types that can be used as keys of a typed cache
+	Key interface {
+		~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+	}
+
+	// Typed is a generic view over a Store that encodes values with a Codec
+	Typed[K Key, V any] struct {
+		store Store
+		codec Codec
+	}
+)
+
+// NewTyped creates a typed cache over store, values are encoded with codec (JSON when nil)
+func NewTyped[K Key, V any](store Store, codec Codec) *Typed[K, V] {
+	if codec == nil {
+		codec = JSON
+	}
+
+	return &Typed[K, V]{
+		store: store,
+		codec: codec,
+	}
+}
+
+// Codec returns the codec used to encode the values
+func (t *Typed[K, V]) Codec() Codec {
+	return t.codec
+}
+
+// Get a value from the cache
+func (t *Typed[K, V]) Get(key K) (V, error) {
+	var value V
+
+	data, err := t.store.GetBytes(formatKey(key))
+	if err != nil {
+		return value, err
+	}
+
+	err = t.codec.Unmarshal(data, &value)
+	return value, err
+}
+
+// Set a value in the cache
+func (t *Typed[K, V]) Set(key K, value V) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.store.SetBytes(formatKey(key), data)
+}
+
+// Delete a value from the cache
+func (t *Typed[K, V]) Delete(key K) error {
+	return t.store.Delete(formatKey(key))
+}
+
+// GetKeys returns all the keys of the cache
+func (t *Typed[K, V]) GetKeys() ([]K, error) {
+	raw, err := t.store.GetKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]K, 0, len(raw))
+	for _, r := range raw {
+		key, err := parseKey[K](r)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// GetAll returns all the decoded values of the cache
+func (t *Typed[K, V]) GetAll() (map[K]V, error) {
+	raw, err := t.store.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[K]V, len(raw))
+	for r, data := range raw {
+		key, err := parseKey[K](r)
+		if err != nil {
+			return nil, err
+		}
+
+		var value V
+		if err := t.codec.Unmarshal([]byte(data), &value); err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func formatKey[K Key](key K) string {
+	return fmt.Sprint(key)
+}
+
+func parseKey[K Key](raw string) (K, error) {
+	var key K
+	if v := reflect.ValueOf(&key).Elem(); v.Kind() == reflect.String {
+		v.SetString(raw)
+		return key, nil
+	}
+
+	if _, err := fmt.Sscan(raw, &key); err != nil {
+		return key, fmt.Errorf("cache: invalid key %q: %w", raw, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type typedRecord struct {
+	Name   string
+	Age    int
+	Score  float64
+	Tags   []string
+	Labels map[string]string
+	Raw    []byte
+	Active bool
+}
+
+func newTestCache(t *testing.T) *V3Cache {
+	c, err := NewCache(t.TempDir())
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		assert.Nil(t, c.Close())
+	})
+	return c
+}
+
+func TestTypedCodecs(t *testing.T) {
+	record := typedRecord{
+		Name:   "test",
+		Age:    42,
+		Score:  1.5,
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"env": "dev"},
+		Raw:    []byte{0, 1, 2},
+		Active: true,
+	}
+
+	codecs := []Codec{JSON, Gob, Binary, Compressed(Binary), Encrypted(JSON), Encrypted(Compressed(Gob))}
+
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			typed := NewTyped[string, typedRecord](newTestCache(t), codec)
+
+			err := typed.Set("record", record)
+			assert.Nil(t, err)
+
+			value, err := typed.Get("record")
+			assert.Nil(t, err)
+			assert.Equal(t, record, value)
+
+			all, err := typed.GetAll()
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]typedRecord{"record": record}, all)
+		})
+	}
+}
+
+func TestTypedIntKeys(t *testing.T) {
+	c := newTestCache(t)
+	typed := NewTyped[int, string](c, nil)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, typed.Set(i, "value"))
+	}
+
+	keys, err := typed.GetKeys()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int{0, 1, 2}, keys)
+
+	assert.Nil(t, typed.Delete(1))
+
+	raw, err := c.Get("2")
+	assert.Nil(t, err)
+	assert.Equal(t, `"value"`, raw)
+}
+
+func TestBinaryCodecAny(t *testing.T) {
+	data, err := Binary.Marshal([]any{"a", int64(1), true, nil})
+	assert.Nil(t, err)
+
+	var value any
+	assert.Nil(t, Binary.Unmarshal(data, &value))
+	assert.Equal(t, []any{"a", int64(1), true, nil}, value)
+}
+
+func TestBinaryCodecTruncated(t *testing.T) {
+	data, err := Binary.Marshal(1.5)
+	assert.Nil(t, err)
+
+	var value float64
+	assert.ErrorIs(t, Binary.Unmarshal(data[:len(data)-1], &value), ErrorInvalidBinary)
+	assert.Nil(t, Binary.Unmarshal(data, &value))
+	assert.Equal(t, 1.5, value)
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/badger/v3/pb"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// metaValue is the user meta of the entries written with a value, Badger does not publish the delete
+	// marker to subscribers so deletes are told apart by the missing user meta and the empty value
+	metaValue byte = 1 << 0
+
+	watchBufferSize = 64
+	// watchMarkerPrefix starts the keys deleted by Watch to know when its subscription is registered,
+	// they are outside of the namespaces and never stored with a value
+	watchMarkerPrefix = "\x00watch\x00"
+	// badgerKeyPrefix starts the internal keys of Badger, like the transaction markers, published to the
+	// subscriptions matching every key
+	badgerKeyPrefix = "!badger!"
+	// watchReadyInterval is the time between the marker writes while the subscription is registered
+	watchReadyInterval = 5 * time.Millisecond
+)
+
+// watchID makes the marker of each Watch unique
+var watchID atomic.Uint64
+
+type (
+	// EventType is the kind of change delivered by Watch
+	EventType int
+
+	// Event is a change of a key, Value is empty for deletes
+	Event struct {
+		Type    EventType
+		Key     string
+		Value   string
+		Version uint64
+	}
+)
+
+const (
+	EventSet EventType = iota + 1
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// newEntry creates the entry written for a key with a value
+func newEntry(key, value []byte) *badger.Entry {
+	return badger.NewEntry(key, value).WithMeta(metaValue)
+}
+
+// Watch delivers the changes of the keys starting with prefix committed by any user of the database after
+// Watch returns, it blocks until the subscription is registered. The channel is closed when ctx is done
+// or the cache is closed.
+func (c *V3Cache) Watch(ctx context.Context, prefix string) <-chan Event {
+	events := make(chan Event, watchBufferSize)
+	ready := make(chan struct{})
+	readyOnce := sync.Once{}
+	marker := []byte(fmt.Sprintf("%s%d", watchMarkerPrefix, watchID.Add(1)))
+	matches := []pb.Match{{Prefix: c.fullKey(prefix)}, {Prefix: marker}}
+
+	go func() {
+		defer close(events)
+		// the subscription may end before it is registered, when ctx is done or the cache is closed
+		defer readyOnce.Do(func() { close(ready) })
+
+		_ = c.db.Subscribe(ctx, func(list *badger.KVList) error {
+			for _, kv := range list.Kv {
+				if bytes.HasPrefix(kv.Key, []byte(watchMarkerPrefix)) {
+					if bytes.Equal(kv.Key, marker) {
+						readyOnce.Do(func() { close(ready) })
+					}
+					continue
+				}
+
+				if bytes.HasPrefix(kv.Key, []byte(badgerKeyPrefix)) {
+					continue
+				}
+
+				event := Event{
+					Type:    EventSet,
+					Key:     c.trimKey(kv.Key),
+					Value:   string(kv.Value),
+					Version: kv.Version,
+				}
+
+				// the entries written without the user meta, like the ones restored by Load, are values too
+				if len(kv.Value) == 0 && (len(kv.Meta) == 0 || kv.Meta[0]&metaValue == 0) {
+					event.Type = EventDelete
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		}, matches)
+	}()
+
+	c.waitSubscribed(ctx, marker, ready)
+	return events
+}
+
+// waitSubscribed deletes marker until the subscription delivers it, Badger does not deliver to a
+// subscription the writes committed before it is registered. A read-only database has no writes to miss.
+func (c *V3Cache) waitSubscribed(ctx context.Context, marker []byte, ready <-chan struct{}) {
+	if c.db.Opts().ReadOnly {
+		return
+	}
+
+	ticker := time.NewTicker(watchReadyInterval)
+	defer ticker.Stop()
+
+	for {
+		_ = c.db.Update(func(txn *badger.Txn) error {
+			return txn.Delete(marker)
+		})
+
+		select {
+		case <-ready:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	c := newTestCache(t)
+	ns, err := c.Namespace("config")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := ns.Watch(ctx, "")
+
+	assert.Nil(t, c.Set("other", "ignored"))
+	assert.Nil(t, ns.Set("level", "debug"))
+	assert.Nil(t, ns.Txn(func(tx Tx) error {
+		return tx.Delete("level")
+	}))
+
+	set := <-events
+	assert.Equal(t, EventSet, set.Type)
+	assert.Equal(t, "level", set.Key)
+	assert.Equal(t, "debug", set.Value)
+
+	deleted := <-events
+	assert.Equal(t, EventDelete, deleted.Type)
+	assert.Equal(t, "level", deleted.Key)
+	assert.Greater(t, deleted.Version, set.Version)
+
+	cancel()
+
+	for range events {
+	}
+}
+
+func TestWatchWithoutMeta(t *testing.T) {
+	c := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the root watch does not report the markers of the other watches
+	events := c.Watch(ctx, "")
+	_ = c.Watch(ctx, "other")
+
+	// the entries restored by Load have no user meta
+	assert.Nil(t, c.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("restored"), []byte("value"))
+	}))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, EventSet, event.Type)
+		assert.Equal(t, "restored", event.Key)
+		assert.Equal(t, "value", event.Value)
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+}
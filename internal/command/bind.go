@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+)
+
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// EnvName returns the env var of a flag, like APP_LOG_LEVEL for the flag log-level of app
+func EnvName(prefix, name string) string {
+	if prefix == "" {
+		return strings.ToUpper(envReplacer.Replace(name))
+	}
+	return strings.ToUpper(envReplacer.Replace(prefix + "_" + name))
+}
+
+// Bind binds the flags declared with the builder to v when the command is executed, each flag is read
+// from the command line, then from the env var PREFIX_FLAG_NAME and then from the config key with the
+// flag name. Only the flags of the executed command, and the persistent flags of its parents, are bound
+// so the sub commands may declare flags with the same name. The flag rules, like AddCommandFlagRequired,
+// are then validated before the command runs
+func (c *BuildCommand) Bind(v *viper.Viper, envPrefix string) {
+	preRun := c.Cmd.PersistentPreRunE
+
+	c.Cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := c.bind(cmd, v, envPrefix); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		// the errors list the flags, the usage is not printed after them
+		c.executed = cmd
+		if err := c.validate(cmd, v); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+}
+
+func (c *BuildCommand) bind(cmd *cobra.Command, v *viper.Viper, envPrefix string) error {
+	for i := range c.Options {
+		state := &c.Options[i]
+		name := state.Flag.Name
+
+		// a flag of another command with the same name
+		if state.flag == nil || cmd.Flags().Lookup(name) != state.flag {
+			continue
+		}
+
+		env := EnvName(envPrefix, name)
+		if err := v.BindPFlag(name, state.flag); err != nil {
+			return err
+		}
+
+		if err := v.BindEnv(name, env); err != nil {
+			return err
+		}
+
+		// the commands reading their flags directly also get the env value
+		if value, ok := os.LookupEnv(env); ok && !state.flag.Changed {
+			if err := state.flag.Value.Set(value); err != nil {
+				return fmt.Errorf("invalid value %q for env %s: %w", value, env, err)
+			}
+		}
+
+		state.bound = true
+	}
+
+	c.Refresh(v)
+	return nil
+}
+
+// Refresh updates the value and Touched of the bound options from v, call it again after the config is read
+func (c *BuildCommand) Refresh(v *viper.Viper) {
+	for i := range c.Options {
+		state := &c.Options[i]
+		if !state.bound {
+			continue
+		}
+
+		state.Touched = v.IsSet(state.Flag.Name)
+		state.Flag.Value = v.Get(state.Flag.Name)
+	}
+}
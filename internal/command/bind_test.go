@@ -0,0 +1,91 @@
+package command
+
+import (
+	"bytes"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "APP_LOG_LEVEL", EnvName("app", "log-level"))
+	assert.Equal(t, "APP_CACHE_DIR", EnvName("app", "cache.dir"))
+	assert.Equal(t, "LOG_LEVEL", EnvName("", "log-level"))
+}
+
+func TestBind(t *testing.T) {
+	t.Setenv("BIND_FROM_ENV", "env")
+
+	var fromEnv string
+	root := NewCommandBuilder("bind").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {
+			fromEnv, _ = cmd.Flags().GetString("from-env")
+		}).
+		AddCommandFlag("from-flag", "", "set on the command line").
+		AddCommandFlag("from-env", "", "set in the env").
+		AddCommandFlag("from-config", "", "set in the config").
+		AddCommandFlag("unset", "default", "not set").
+		Build()
+
+	v := viper.New()
+	root.Bind(v, "bind")
+
+	root.Cmd.SetArgs([]string{"--from-flag=flag"})
+	assert.Nil(t, root.Cmd.Execute())
+
+	assert.Equal(t, "env", fromEnv, "the env value is set on the flag")
+	assert.Equal(t, "flag", v.GetString("from-flag"))
+	assert.Equal(t, "env", v.GetString("from-env"))
+	assert.Equal(t, "default", v.GetString("unset"))
+
+	v.SetConfigType("json")
+	assert.Nil(t, v.ReadConfig(bytes.NewBufferString(`{"from-config": "config", "from-flag": "ignored"}`)))
+	root.Refresh(v)
+
+	touched := make(map[string]bool)
+	values := make(map[string]any)
+	for _, state := range root.Options {
+		touched[state.Flag.Name] = state.Touched
+		values[state.Flag.Name] = state.Flag.Value
+	}
+
+	assert.Equal(t, map[string]bool{"from-flag": true, "from-env": true, "from-config": true, "unset": false}, touched)
+	assert.Equal(t, map[string]any{"from-flag": "flag", "from-env": "env", "from-config": "config", "unset": "default"}, values)
+}
+
+func TestBindExecutedCommand(t *testing.T) {
+	root := NewCommandBuilder("bind-root").
+		AddCommandFlag("output", "root", "output of root").
+		Build()
+	sub := NewCommandBuilder("sub").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("output", "sub", "output of sub").
+		Build()
+	root.AddCommand(sub)
+
+	v := viper.New()
+	root.Bind(v, "bind")
+
+	root.Cmd.SetArgs([]string{"sub", "--output=set"})
+	assert.Nil(t, root.Cmd.Execute())
+
+	assert.Equal(t, "set", v.GetString("output"))
+	assert.False(t, root.Options[0].Touched, "the flag of root is not bound")
+	assert.True(t, root.Options[1].Touched)
+}
+
+func TestBindInvalidEnv(t *testing.T) {
+	t.Setenv("BIND_COUNT", "many")
+
+	root := NewCommandBuilder("bind-invalid").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("count", 1, "count").
+		SilentUsage().
+		SilentErrors().
+		Build()
+	root.Bind(viper.New(), "bind")
+
+	root.Cmd.SetArgs([]string{})
+	assert.ErrorContains(t, root.Cmd.Execute(), "BIND_COUNT")
+}
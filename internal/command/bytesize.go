@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	_           = iota
+	KB ByteSize = 1 << (10 * iota)
+	MB
+	GB
+	TB
+)
+
+var (
+	ErrorInvalidByteSize = fmt.Errorf("command: invalid byte size, use a number with an optional unit like 512KB, 10MB or 1.5GB")
+
+	byteUnits = map[string]ByteSize{
+		"":    1,
+		"b":   1,
+		"k":   KB,
+		"kb":  KB,
+		"kib": KB,
+		"m":   MB,
+		"mb":  MB,
+		"mib": MB,
+		"g":   GB,
+		"gb":  GB,
+		"gib": GB,
+		"t":   TB,
+		"tb":  TB,
+		"tib": TB,
+	}
+)
+
+// ByteSize is a flag value in bytes written with an optional unit like "10MB", the units are powers of 1024
+type ByteSize uint64
+
+// ParseByteSize parses a size like "512", "64KB", "10MB" or "1.5GiB", the unit ignores case
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrorInvalidByteSize, s)
+	}
+
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrorInvalidByteSize, s)
+	}
+
+	size := number * float64(unit)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("%w: %q", ErrorInvalidByteSize, s)
+	}
+	return ByteSize(size), nil
+}
+
+// String returns the size with the largest unit it is a whole number of
+func (b *ByteSize) String() string {
+	for _, unit := range []struct {
+		size ByteSize
+		name string
+	}{{TB, "TB"}, {GB, "GB"}, {MB, "MB"}, {KB, "KB"}} {
+		if *b >= unit.size && *b%unit.size == 0 {
+			return fmt.Sprintf("%d%s", *b/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(*b))
+}
+
+func (b *ByteSize) Set(s string) error {
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+
+	*b = size
+	return nil
+}
+
+func (b *ByteSize) Type() string {
+	return "bytes"
+}
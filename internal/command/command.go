@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"os"
 )
 
@@ -10,11 +11,18 @@ type (
 	BuildCommand struct {
 		Cmd     *cobra.Command
 		Options []State
+		rules   []rule
+		// executed is the command run, its rules are checked again by Validate
+		executed *cobra.Command
 	}
 
+	// State is a flag declared with the builder, Touched reports whether the user set it on the command
+	// line, in the env or in the config, see Bind
 	State struct {
-		Touched any
+		Touched bool
 		Flag    Flag
+		flag    *pflag.Flag
+		bound   bool
 	}
 
 	Flag struct {
@@ -36,9 +44,8 @@ func NewCommandBuilder(name string) *BuildCommand {
 func (c *BuildCommand) AddCommand(buildCommand *BuildCommand) *BuildCommand {
 	c.Cmd.AddCommand(buildCommand.Cmd)
 	// add options to parent command
-	for k, v := range buildCommand.Options {
-		c.Options[k] = v
-	}
+	c.Options = append(c.Options, buildCommand.Options...)
+	c.rules = append(c.rules, buildCommand.rules...)
 	return c
 }
 
@@ -57,63 +64,46 @@ func (c *BuildCommand) AddCommandRun(run func(cmd *cobra.Command, args []string)
 	return c
 }
 
-func (c *BuildCommand) AddCommandFlag(name string, defaultValue any, description string) *BuildCommand {
-	c.addCommandFlag(name, defaultValue, description, false)
-	if err := c.Cmd.Flags().MarkHidden(name); err != nil {
-		c.Cmd.Printf("Error binding flag: %s\n", err)
-		os.Exit(1)
-	}
+func (c *BuildCommand) AddCommandRunE(run func(cmd *cobra.Command, args []string) error) *BuildCommand {
+	c.Cmd.RunE = run
+	return c
+}
+
+func (c *BuildCommand) AddCommandArgs(args cobra.PositionalArgs) *BuildCommand {
+	c.Cmd.Args = args
 	return c
 }
 
+func (c *BuildCommand) AddCommandFlag(name string, defaultValue any, description string) *BuildCommand {
+	return c.addCommandFlag(name, defaultValue, description, false)
+}
+
 func (c *BuildCommand) addCommandFlag(name string, defaultValue any, description string, persistent bool) *BuildCommand {
-	state := State{
-		Touched: true,
+	if err := DefineFlag(c.Cmd, persistent, name, defaultValue, description); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	flags := c.Cmd.Flags()
+	if persistent {
+		flags = c.Cmd.PersistentFlags()
+	}
+
+	c.Options = append(c.Options, State{
 		Flag: Flag{
 			Name:        name,
 			Description: description,
 			Default:     defaultValue,
 			Value:       defaultValue,
 		},
-	}
-
-	c.Options = append(c.Options, state)
-
-	switch v := defaultValue.(type) {
-	case bool:
-		if persistent {
-			c.Cmd.PersistentFlags().Bool(name, v, description)
-		} else {
-			c.Cmd.Flags().Bool(name, v, description)
-		}
-	case string:
-		if persistent {
-			c.Cmd.PersistentFlags().String(name, v, description)
-		} else {
-			c.Cmd.Flags().String(name, v, description)
-		}
-	case int, int8, int16, int32, int64:
-		if persistent {
-			c.Cmd.PersistentFlags().Int64(name, v.(int64), description)
-		} else {
-			c.Cmd.Flags().Int64(name, v.(int64), description)
-		}
-	default:
-		fmt.Printf("Invalid type: %s\n", v)
-		os.Exit(1)
-	}
+		flag: flags.Lookup(name),
+	})
 
 	return c
 }
 
 func (c *BuildCommand) AddCommandFlagPersistent(name string, defaultValue any, description string) *BuildCommand {
-	c.addCommandFlag(name, defaultValue, description, true)
-	if err := c.Cmd.PersistentFlags().MarkHidden(name); err != nil {
-		c.Cmd.Printf("Error binding flag: %s\n", err)
-		os.Exit(1)
-	}
-
-	return c
+	return c.addCommandFlag(name, defaultValue, description, true)
 }
 
 func (c *BuildCommand) SilentUsage() *BuildCommand {
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DocsMan      = "man"
+	DocsMarkdown = "markdown"
+	DocsBash     = "bash"
+	DocsZsh      = "zsh"
+	DocsFish     = "fish"
+)
+
+var (
+	ErrorInvalidDocsFormat = fmt.Errorf("command: invalid docs format, use %s, %s, %s, %s or %s", DocsMan, DocsMarkdown, DocsBash, DocsZsh, DocsFish)
+
+	// DocsFormats are the formats written by GenerateDocs
+	DocsFormats = []string{DocsMan, DocsMarkdown, DocsBash, DocsZsh, DocsFish}
+)
+
+// GenerateDocs writes the docs of the command and its sub commands to dir: a man page or a Markdown page per
+// command, or the completion script of a shell, named like the shell loads it. The hidden flags and commands
+// are left out
+func (c *BuildCommand) GenerateDocs(format, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("command: failed to create docs directory: %w", err)
+	}
+
+	// the generated files do not change on every run
+	disableAutoGenTag(c.Cmd)
+
+	name := c.Cmd.Name()
+
+	switch format {
+	case DocsMan:
+		return doc.GenManTree(c.Cmd, &doc.GenManHeader{
+			Title:   strings.ToUpper(name),
+			Section: "1",
+			Source:  name,
+		}, dir)
+	case DocsMarkdown:
+		return doc.GenMarkdownTree(c.Cmd, dir)
+	case DocsBash:
+		return c.Cmd.GenBashCompletionFileV2(filepath.Join(dir, name+".bash"), true)
+	case DocsZsh:
+		return c.Cmd.GenZshCompletionFile(filepath.Join(dir, "_"+name))
+	case DocsFish:
+		return c.Cmd.GenFishCompletionFile(filepath.Join(dir, name+".fish"), true)
+	default:
+		return fmt.Errorf("%w: %s", ErrorInvalidDocsFormat, format)
+	}
+}
+
+func disableAutoGenTag(cmd *cobra.Command) {
+	cmd.DisableAutoGenTag = true
+	for _, sub := range cmd.Commands() {
+		disableAutoGenTag(sub)
+	}
+}
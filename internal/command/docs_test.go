@@ -0,0 +1,57 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	root := NewCommandBuilder("app").
+		AddCommandShortMessage("application").
+		AddCommandFlag("visible", "", "visible flag").
+		AddCommandFlag("secret", "", "hidden flag").
+		AddCommandFlagHidden("secret").
+		Build()
+	sub := NewCommandBuilder("sub").
+		AddCommandShortMessage("sub command").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		Build()
+	root.AddCommand(sub)
+
+	tests := []struct {
+		format string
+		files  []string
+	}{
+		{format: DocsMan, files: []string{"app.1", "app-sub.1"}},
+		{format: DocsMarkdown, files: []string{"app.md", "app_sub.md"}},
+		{format: DocsBash, files: []string{"app.bash"}},
+		{format: DocsZsh, files: []string{"_app"}},
+		{format: DocsFish, files: []string{"app.fish"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "docs")
+			assert.Nil(t, root.GenerateDocs(tt.format, dir))
+
+			for _, file := range tt.files {
+				_, err := os.Stat(filepath.Join(dir, file))
+				assert.Nil(t, err, file)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	assert.Nil(t, root.GenerateDocs(DocsMarkdown, dir))
+
+	page, err := os.ReadFile(filepath.Join(dir, "app.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(page), "--visible")
+	assert.NotContains(t, string(page), "--secret")
+	assert.NotContains(t, string(page), "Auto generated")
+
+	assert.ErrorIs(t, root.GenerateDocs("pdf", t.TempDir()), ErrorInvalidDocsFormat)
+}
@@ -0,0 +1,191 @@
+package command
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrorInvalidFlagType = fmt.Errorf("command: invalid flag type")
+	ErrorFlagNotFound    = fmt.Errorf("command: flag not found")
+	ErrorFlagValueType   = fmt.Errorf("command: flag value has a different type")
+
+	registry = &flagRegistry{}
+)
+
+type (
+	// flagRegistry keeps the value of the flags defined by the builders, read by Get
+	flagRegistry struct {
+		mutex sync.RWMutex
+		flags []registeredFlag
+	}
+
+	// registeredFlag is the value of a flag of cmd, a pointer to the parsed value or the pflag.Value
+	registeredFlag struct {
+		cmd   *cobra.Command
+		name  string
+		value any
+	}
+)
+
+// DefineFlag adds a flag to cmd, to its persistent flags when persistent is set, the flag type is the type
+// of defaultValue: bool, string, the int, uint and float types, time.Duration, ByteSize, net.IP, net.IPNet,
+// the slices of string, int, int64, uint, bool, float64, time.Duration and net.IP, the maps of string to
+// string, int and int64 or any pflag.Value, which is used as the flag value
+func DefineFlag(cmd *cobra.Command, persistent bool, name string, defaultValue any, description string) error {
+	flags := cmd.Flags()
+	if persistent {
+		flags = cmd.PersistentFlags()
+	}
+
+	value, err := defineFlag(flags, name, defaultValue, description)
+	if err != nil {
+		return err
+	}
+
+	registry.add(cmd, name, value)
+	return nil
+}
+
+func defineFlag(flags *pflag.FlagSet, name string, defaultValue any, description string) (any, error) {
+	switch v := defaultValue.(type) {
+	case bool:
+		return flags.Bool(name, v, description), nil
+	case string:
+		return flags.String(name, v, description), nil
+	case int:
+		return flags.Int(name, v, description), nil
+	case int8:
+		return flags.Int8(name, v, description), nil
+	case int16:
+		return flags.Int16(name, v, description), nil
+	case int32:
+		return flags.Int32(name, v, description), nil
+	case int64:
+		return flags.Int64(name, v, description), nil
+	case uint:
+		return flags.Uint(name, v, description), nil
+	case uint8:
+		return flags.Uint8(name, v, description), nil
+	case uint16:
+		return flags.Uint16(name, v, description), nil
+	case uint32:
+		return flags.Uint32(name, v, description), nil
+	case uint64:
+		return flags.Uint64(name, v, description), nil
+	case float32:
+		return flags.Float32(name, v, description), nil
+	case float64:
+		return flags.Float64(name, v, description), nil
+	case time.Duration:
+		return flags.Duration(name, v, description), nil
+	case ByteSize:
+		size := new(ByteSize)
+		*size = v
+		flags.Var(size, name, description)
+		return size, nil
+	case net.IP:
+		return flags.IP(name, v, description), nil
+	case net.IPNet:
+		return flags.IPNet(name, v, description), nil
+	case []string:
+		return flags.StringSlice(name, v, description), nil
+	case []int:
+		return flags.IntSlice(name, v, description), nil
+	case []int64:
+		return flags.Int64Slice(name, v, description), nil
+	case []uint:
+		return flags.UintSlice(name, v, description), nil
+	case []bool:
+		return flags.BoolSlice(name, v, description), nil
+	case []float64:
+		return flags.Float64Slice(name, v, description), nil
+	case []time.Duration:
+		return flags.DurationSlice(name, v, description), nil
+	case []net.IP:
+		return flags.IPSlice(name, v, description), nil
+	case map[string]string:
+		return flags.StringToString(name, v, description), nil
+	case map[string]int:
+		return flags.StringToInt(name, v, description), nil
+	case map[string]int64:
+		return flags.StringToInt64(name, v, description), nil
+	case pflag.Value:
+		flags.Var(v, name, description)
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: %s %T", ErrorInvalidFlagType, name, defaultValue)
+	}
+}
+
+// Get returns the value of the flag name, the flag of the executed command when more than one command
+// defines it. T is the type of the default value the flag was defined with, or the pflag.Value type
+func Get[T any](name string) (T, error) {
+	var zero T
+
+	value, ok := registry.lookup(name)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrorFlagNotFound, name)
+	}
+
+	switch v := value.(type) {
+	case *T:
+		return *v, nil
+	case T:
+		return v, nil
+	default:
+		return zero, fmt.Errorf("%w: %s is %T", ErrorFlagValueType, name, value)
+	}
+}
+
+func (r *flagRegistry) add(cmd *cobra.Command, name string, value any) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.flags = append(r.flags, registeredFlag{cmd: cmd, name: name, value: value})
+}
+
+// lookup returns the value of the flag name of the executed command, or the persistent flag of one
+// of its parents, the first flag defined with the name otherwise
+func (r *flagRegistry) lookup(name string) (any, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var inherited, found any
+	for _, flag := range r.flags {
+		if flag.name != name {
+			continue
+		}
+
+		if flag.cmd.CalledAs() != "" {
+			return flag.value, true
+		}
+
+		if inherited == nil && flag.cmd.PersistentFlags().Lookup(name) != nil && executedChild(flag.cmd) {
+			inherited = flag.value
+		}
+
+		if found == nil {
+			found = flag.value
+		}
+	}
+
+	if inherited != nil {
+		return inherited, true
+	}
+	return found, found != nil
+}
+
+// executedChild reports whether one of the sub commands of cmd is the executed command
+func executedChild(cmd *cobra.Command) bool {
+	for _, sub := range cmd.Commands() {
+		if sub.CalledAs() != "" || executedChild(sub) {
+			return true
+		}
+	}
+	return false
+}
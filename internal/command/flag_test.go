@@ -0,0 +1,163 @@
+package command
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+// level is a custom pflag.Value
+type level struct {
+	value string
+}
+
+func (l *level) String() string { return l.value }
+
+func (l *level) Set(s string) error {
+	if s != "debug" && s != "info" {
+		return errors.New("invalid level")
+	}
+	l.value = s
+	return nil
+}
+
+func (l *level) Type() string { return "level" }
+
+func TestFlagTypes(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+
+	cmd := NewCommandBuilder("types").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("types-int", 1, "int").
+		AddCommandFlag("types-uint", uint(2), "uint").
+		AddCommandFlag("types-float", 1.5, "float64").
+		AddCommandFlag("types-duration", time.Second, "duration").
+		AddCommandFlag("types-size", 10*MB, "byte size").
+		AddCommandFlag("types-ip", net.ParseIP("127.0.0.1"), "ip").
+		AddCommandFlag("types-cidr", *network, "cidr").
+		AddCommandFlag("types-slice", []string{"a"}, "string slice").
+		AddCommandFlag("types-map", map[string]string{}, "map").
+		AddCommandFlag("types-level", &level{value: "info"}, "custom value").
+		Build()
+
+	cmd.Cmd.SetArgs([]string{
+		"--types-int=3", "--types-uint=4", "--types-float=2.5", "--types-duration=1m", "--types-size=1.5GB",
+		"--types-ip=10.1.2.3", "--types-cidr=192.168.0.0/16", "--types-slice=b,c", "--types-map=k=v",
+		"--types-level=debug",
+	})
+	assert.Nil(t, cmd.Cmd.Execute())
+
+	i, err := Get[int]("types-int")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, i)
+
+	u, err := Get[uint]("types-uint")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(4), u)
+
+	f, err := Get[float64]("types-float")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.5, f)
+
+	d, err := Get[time.Duration]("types-duration")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, d)
+
+	size, err := Get[ByteSize]("types-size")
+	assert.Nil(t, err)
+	assert.Equal(t, 3*GB/2, size)
+
+	ip, err := Get[net.IP]("types-ip")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.1.2.3", ip.String())
+
+	cidr, err := Get[net.IPNet]("types-cidr")
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.0.0/16", cidr.String())
+
+	slice, err := Get[[]string]("types-slice")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "c"}, slice)
+
+	m, err := Get[map[string]string]("types-map")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"k": "v"}, m)
+
+	l, err := Get[*level]("types-level")
+	assert.Nil(t, err)
+	assert.Equal(t, "debug", l.value)
+
+	_, err = Get[string]("types-int")
+	assert.ErrorIs(t, err, ErrorFlagValueType)
+
+	_, err = Get[string]("types-missing")
+	assert.ErrorIs(t, err, ErrorFlagNotFound)
+}
+
+func TestDefineFlagInvalidType(t *testing.T) {
+	err := DefineFlag(&cobra.Command{Use: "invalid"}, false, "invalid", struct{}{}, "")
+	assert.ErrorIs(t, err, ErrorInvalidFlagType)
+}
+
+func TestGetExecutedCommand(t *testing.T) {
+	parent := NewCommandBuilder("parent").
+		AddCommandFlagPersistent("executed-shared", "parent", "shared").
+		AddCommandFlag("executed-local", "parent", "local").
+		Build()
+	child := NewCommandBuilder("child").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("executed-local", "child", "local").
+		Build()
+	parent.AddCommand(child)
+
+	parent.Cmd.SetArgs([]string{"child", "--executed-shared=set", "--executed-local=set"})
+	assert.Nil(t, parent.Cmd.Execute())
+
+	shared, err := Get[string]("executed-shared")
+	assert.Nil(t, err)
+	assert.Equal(t, "set", shared)
+
+	local, err := Get[string]("executed-local")
+	assert.Nil(t, err)
+	assert.Equal(t, "set", local)
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ByteSize
+		err      bool
+	}{
+		{value: "512", expected: 512},
+		{value: "512B", expected: 512},
+		{value: "64KB", expected: 64 * KB},
+		{value: "10MB", expected: 10 * MB},
+		{value: "10 mib", expected: 10 * MB},
+		{value: "1.5GB", expected: 3 * GB / 2},
+		{value: "2T", expected: 2 * TB},
+		{value: "", err: true},
+		{value: "MB", err: true},
+		{value: "-1MB", err: true},
+		{value: "10XB", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := ParseByteSize(tt.value)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrorInvalidByteSize)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, size)
+		})
+	}
+
+	for size, expected := range map[ByteSize]string{100: "100B", 1536 * KB: "1536KB", 10 * MB: "10MB", 2 * TB: "2TB"} {
+		assert.Equal(t, expected, size.String())
+	}
+}
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sort"
+)
+
+// categoryAnnotation is the flag annotation holding the help category of the flag
+const categoryAnnotation = "category"
+
+// usageTemplate is the cobra usage template with the flags grouped by category
+const usageTemplate = `Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}{{$cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+Available Commands:{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{.Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+Additional Commands:{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}{{range flagCategories .LocalFlags ""}}
+
+{{.Title}}:
+{{.Usages | trimTrailingWhitespaces}}{{end}}{{end}}{{if .HasAvailableInheritedFlags}}{{range flagCategories .InheritedFlags "Global "}}
+
+{{.Title}}:
+{{.Usages | trimTrailingWhitespaces}}{{end}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
+
+// flagCategory is a section of the flags in the help output
+type flagCategory struct {
+	Title  string
+	Usages string
+}
+
+func init() {
+	cobra.AddTemplateFunc("flagCategories", flagCategories)
+}
+
+// AddCommandFlagHidden hides the flags from the help output and the generated docs, they can still be set
+func (c *BuildCommand) AddCommandFlagHidden(names ...string) *BuildCommand {
+	for _, name := range names {
+		if flag := declaredFlag(c.Cmd, name); flag != nil {
+			flag.Hidden = true
+		}
+	}
+	return c
+}
+
+// AddCommandFlagCategory lists the flags under their own "<category> Flags" section in the help output
+func (c *BuildCommand) AddCommandFlagCategory(category string, names ...string) *BuildCommand {
+	for _, name := range names {
+		flag := declaredFlag(c.Cmd, name)
+		if flag == nil {
+			continue
+		}
+
+		if flag.Annotations == nil {
+			flag.Annotations = make(map[string][]string)
+		}
+		flag.Annotations[categoryAnnotation] = []string{category}
+	}
+
+	c.Cmd.SetUsageTemplate(usageTemplate)
+	return c
+}
+
+// flagCategories groups the visible flags by category, the flags without one are listed first, the titles
+// start with prefix
+func flagCategories(flags *pflag.FlagSet, prefix string) []flagCategory {
+	sets := make(map[string]*pflag.FlagSet)
+	names := make([]string, 0)
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		category := ""
+		if values := flag.Annotations[categoryAnnotation]; len(values) > 0 {
+			category = values[0]
+		}
+
+		set, ok := sets[category]
+		if !ok {
+			set = pflag.NewFlagSet(category, pflag.ContinueOnError)
+			sets[category] = set
+			names = append(names, category)
+		}
+		set.AddFlag(flag)
+	})
+
+	sort.Strings(names)
+
+	categories := make([]flagCategory, 0, len(names))
+	for _, name := range names {
+		title := prefix + "Flags"
+		if name != "" {
+			title = prefix + name + " Flags"
+		}
+
+		categories = append(categories, flagCategory{
+			Title:  title,
+			Usages: sets[name].FlagUsages(),
+		})
+	}
+	return categories
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"bytes"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestFlagCategories(t *testing.T) {
+	root := NewCommandBuilder("help").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("verbose", false, "verbose output").
+		AddCommandFlag("log-level", "", "log level").
+		AddCommandFlag("cache-dir", "", "cache directory").
+		AddCommandFlag("secret", "", "hidden flag").
+		AddCommandFlagPersistent("config", "", "config file").
+		AddCommandFlagHidden("secret").
+		AddCommandFlagCategory("Log", "log-level").
+		AddCommandFlagCategory("Cache", "cache-dir").
+		AddCommandFlagCategory("Config", "config").
+		Build()
+	sub := NewCommandBuilder("sub").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		Build()
+	root.AddCommand(sub)
+
+	out := &bytes.Buffer{}
+	root.Cmd.SetOut(out)
+	root.Cmd.SetArgs([]string{"--help"})
+	assert.Nil(t, root.Cmd.Execute())
+
+	help := out.String()
+	assert.NotContains(t, help, "--secret")
+	assert.Contains(t, help, "Flags:\n  -h, --help")
+	assert.Contains(t, help, "--verbose")
+
+	// the uncategorized flags come first, then the categories by name
+	sections := []string{"\nFlags:", "\nCache Flags:", "\nConfig Flags:", "\nLog Flags:"}
+	last := -1
+	for _, section := range sections {
+		i := strings.Index(help, section)
+		assert.Greater(t, i, last, section)
+		last = i
+	}
+
+	out.Reset()
+	root.Cmd.SetArgs([]string{"sub", "--help"})
+	assert.Nil(t, root.Cmd.Execute())
+	assert.Contains(t, out.String(), "Global Config Flags:\n      --config string")
+}
@@ -0,0 +1,169 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrorFlagRequired           = fmt.Errorf("command: required flag not set")
+	ErrorFlagsMutuallyExclusive = fmt.Errorf("command: only one of the flags can be set")
+	ErrorFlagsOneRequired       = fmt.Errorf("command: one of the flags must be set")
+	ErrorFlagInvalidValue       = fmt.Errorf("command: invalid flag value")
+)
+
+type (
+	// Validator checks the value of a flag as printed by it, the slices are checked element by element
+	Validator func(value string) error
+
+	// rule validates flags of cmd, check receives the flags that are set and the viper instance they are
+	// bound to
+	rule struct {
+		cmd   *cobra.Command
+		names []string
+		check func(set []*pflag.Flag, v *viper.Viper) []error
+	}
+)
+
+// AddCommandFlagRequired requires the flags to be set on the command line or in the env
+func (c *BuildCommand) AddCommandFlagRequired(names ...string) *BuildCommand {
+	for _, name := range names {
+		name := name
+		c.addRule([]string{name}, func(set []*pflag.Flag, _ *viper.Viper) []error {
+			if len(set) == 0 {
+				return []error{fmt.Errorf("%w: --%s", ErrorFlagRequired, name)}
+			}
+			return nil
+		})
+	}
+	return c
+}
+
+// AddCommandFlagsMutuallyExclusive allows at most one of the flags to be set
+func (c *BuildCommand) AddCommandFlagsMutuallyExclusive(names ...string) *BuildCommand {
+	return c.addRule(names, func(set []*pflag.Flag, _ *viper.Viper) []error {
+		if len(set) > 1 {
+			return []error{fmt.Errorf("%w: %s are set", ErrorFlagsMutuallyExclusive, flagList(set))}
+		}
+		return nil
+	})
+}
+
+// AddCommandFlagsOneRequired requires at least one of the flags to be set
+func (c *BuildCommand) AddCommandFlagsOneRequired(names ...string) *BuildCommand {
+	return c.addRule(names, func(set []*pflag.Flag, _ *viper.Viper) []error {
+		if len(set) == 0 {
+			return []error{fmt.Errorf("%w: --%s", ErrorFlagsOneRequired, strings.Join(names, ", --"))}
+		}
+		return nil
+	})
+}
+
+// AddCommandFlagEnum restricts the value of the flag, when it is set, to values
+func (c *BuildCommand) AddCommandFlagEnum(name string, values ...string) *BuildCommand {
+	return c.AddCommandFlagValidator(name, func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("use %s", strings.Join(values, ", "))
+		}
+		return nil
+	})
+}
+
+// AddCommandFlagValidator checks the value of the flag with validate when it is set, on the command line,
+// in the env or in the config, see Validate
+func (c *BuildCommand) AddCommandFlagValidator(name string, validate Validator) *BuildCommand {
+	return c.addRule([]string{name}, func(set []*pflag.Flag, v *viper.Viper) []error {
+		for _, flag := range set {
+			for _, value := range flagValues(flag, v) {
+				if err := validate(value); err != nil {
+					return []error{fmt.Errorf("%w: --%s=%s: %w", ErrorFlagInvalidValue, name, value, err)}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (c *BuildCommand) addRule(names []string, check func(set []*pflag.Flag, v *viper.Viper) []error) *BuildCommand {
+	c.rules = append(c.rules, rule{
+		cmd:   c.Cmd,
+		names: names,
+		check: check,
+	})
+	return c
+}
+
+// validate checks the rules of the executed command and of the persistent flags of its parents, a flag
+// is set when it is on the command line or in the env, all the errors are returned together
+func (c *BuildCommand) validate(cmd *cobra.Command, v *viper.Viper) error {
+	errs := make([]error, 0)
+
+	for _, r := range c.rules {
+		set := make([]*pflag.Flag, 0, len(r.names))
+		applies := true
+
+		for _, name := range r.names {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil || flag != declaredFlag(r.cmd, name) {
+				applies = false
+				break
+			}
+
+			if flag.Changed || v.IsSet(name) {
+				set = append(set, flag)
+			}
+		}
+
+		if applies {
+			errs = append(errs, r.check(set, v)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the rules of the executed command again with the values of v, the rules are checked before
+// the command runs so call it once the config is read to check the values set in it
+func (c *BuildCommand) Validate(v *viper.Viper) error {
+	if c.executed == nil {
+		return nil
+	}
+	return c.validate(c.executed, v)
+}
+
+// declaredFlag returns the flag name declared by cmd
+func declaredFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if flag := cmd.PersistentFlags().Lookup(name); flag != nil {
+		return flag
+	}
+	return cmd.Flags().Lookup(name)
+}
+
+// flagValues returns the elements of a slice flag or the value of the others, the value set on the command
+// line or else the one of v, read from the env or the config
+func flagValues(flag *pflag.Flag, v *viper.Viper) []string {
+	slice, isSlice := flag.Value.(pflag.SliceValue)
+
+	switch {
+	case flag.Changed && isSlice:
+		return slice.GetSlice()
+	case flag.Changed:
+		return []string{flag.Value.String()}
+	case isSlice:
+		return v.GetStringSlice(flag.Name)
+	default:
+		return []string{v.GetString(flag.Name)}
+	}
+}
+
+func flagList(flags []*pflag.Flag) string {
+	names := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		names = append(names, "--"+flag.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,176 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func validateCommand(run *bool) *BuildCommand {
+	return NewCommandBuilder("validate").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {
+			*run = true
+		}).
+		AddCommandFlag("name", "", "required").
+		AddCommandFlag("config", "", "config file").
+		AddCommandFlag("config-string", "", "config string").
+		AddCommandFlag("format", "json", "format").
+		AddCommandFlag("tags", []string{}, "tags").
+		AddCommandFlag("port", 8080, "port").
+		AddCommandFlagRequired("name").
+		AddCommandFlagsMutuallyExclusive("config", "config-string").
+		AddCommandFlagsOneRequired("config", "config-string").
+		AddCommandFlagEnum("format", "json", "csv").
+		AddCommandFlagEnum("tags", "a", "b").
+		AddCommandFlagValidator("port", func(value string) error {
+			if value == "0" {
+				return errors.New("port must not be 0")
+			}
+			return nil
+		}).
+		SilentUsage().
+		SilentErrors().
+		Build()
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		errs []error
+	}{
+		{
+			name: "valid",
+			args: []string{"--name=n", "--config=c", "--format=csv", "--tags=a,b", "--port=1"},
+		},
+		{
+			name: "required from env",
+			args: []string{"--config=c"},
+			env:  map[string]string{"VALIDATE_NAME": "n"},
+		},
+		{
+			name: "missing",
+			errs: []error{ErrorFlagRequired, ErrorFlagsOneRequired},
+		},
+		{
+			name: "exclusive",
+			args: []string{"--name=n", "--config=c", "--config-string=s"},
+			errs: []error{ErrorFlagsMutuallyExclusive},
+		},
+		{
+			name: "invalid values",
+			args: []string{"--name=n", "--config=c", "--format=xml", "--tags=a,c", "--port=0"},
+			errs: []error{ErrorFlagInvalidValue},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			run := false
+			cmd := validateCommand(&run)
+			cmd.Bind(viper.New(), "validate")
+			cmd.Cmd.SetArgs(tt.args)
+
+			err := cmd.Cmd.Execute()
+			assert.Equal(t, len(tt.errs) == 0, run, "the command only runs when the flags are valid")
+
+			if len(tt.errs) == 0 {
+				assert.Nil(t, err)
+				return
+			}
+
+			for _, expected := range tt.errs {
+				assert.ErrorIs(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	run := false
+	cmd := validateCommand(&run)
+	cmd.Bind(viper.New(), "validate")
+	cmd.Cmd.SetArgs([]string{"--name=n", "--config=c", "--format=xml", "--tags=a,c", "--port=0"})
+
+	err := cmd.Cmd.Execute()
+	assert.EqualError(t, err, `command: invalid flag value: --format=xml: use json, csv
+command: invalid flag value: --tags=c: use a, b
+command: invalid flag value: --port=0: port must not be 0`)
+}
+
+func TestValidateSubCommand(t *testing.T) {
+	run := false
+	root := NewCommandBuilder("validate-root").
+		AddCommandFlag("local", "", "local flag of root").
+		AddCommandFlagPersistent("shared", "", "persistent flag of root").
+		AddCommandFlagRequired("local").
+		AddCommandFlagEnum("shared", "x").
+		Build()
+	sub := NewCommandBuilder("sub").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {
+			run = true
+		}).
+		Build()
+	root.AddCommand(sub).SilentUsage().SilentErrors()
+	root.Bind(viper.New(), "validate")
+
+	root.Cmd.SetArgs([]string{"sub"})
+	assert.Nil(t, root.Cmd.Execute(), "the local flags of the parent are not required")
+	assert.True(t, run)
+
+	root.Cmd.SetArgs([]string{"sub", "--shared=y"})
+	assert.ErrorIs(t, root.Cmd.Execute(), ErrorFlagInvalidValue)
+}
+
+func TestValidateNoUsage(t *testing.T) {
+	root := NewCommandBuilder("validate-usage").
+		AddCommandRun(func(cmd *cobra.Command, args []string) {}).
+		AddCommandFlag("name", "", "required").
+		AddCommandFlagRequired("name").
+		Build()
+	root.Bind(viper.New(), "validate")
+
+	out := &bytes.Buffer{}
+	root.Cmd.SetOut(out)
+	root.Cmd.SetErr(out)
+	root.Cmd.SetArgs([]string{})
+
+	assert.ErrorIs(t, root.Cmd.Execute(), ErrorFlagRequired)
+	assert.Equal(t, "Error: command: required flag not set: --name\n", out.String())
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Setenv("VALIDATE_PORT", "0")
+
+	run := false
+	cmd := validateCommand(&run)
+	v := viper.New()
+	cmd.Bind(v, "validate")
+	cmd.Cmd.SetArgs([]string{"--name=n", "--config=c"})
+
+	// the env value is checked before the command runs
+	assert.ErrorIs(t, cmd.Cmd.Execute(), ErrorFlagInvalidValue)
+	assert.False(t, run)
+
+	t.Setenv("VALIDATE_PORT", "1")
+	assert.Nil(t, cmd.Cmd.Execute())
+	assert.True(t, run)
+	assert.Nil(t, cmd.Validate(v))
+
+	// the config is read once the command ran
+	v.SetConfigType("json")
+	assert.Nil(t, v.ReadConfig(strings.NewReader(`{"format": "xml", "tags": ["a", "c"]}`)))
+
+	err := cmd.Validate(v)
+	assert.EqualError(t, err, `command: invalid flag value: --format=xml: use json, csv
+command: invalid flag value: --tags=c: use a, b`)
+}
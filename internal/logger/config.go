@@ -3,6 +3,8 @@ package logger
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 const (
@@ -14,41 +16,62 @@ const (
 	defaultLocalTime   = true
 	defaultCompress    = true
 	defaultStdout      = true
+	defaultEncoder     = EncoderAuto
+	defaultTimeFormat  = TimeFormatISO8601
 )
 
 type (
 	Config struct {
-		LogDir      string
-		ServiceName string
-		MaxFileSize int
-		MaxAge      int
-		MaxBackups  int
-		LocalTime   bool
-		Compress    bool
-		Filename    string
-		Stdout      bool
-		Instance    string
-		validated   bool
+		LogDir         string
+		ServiceName    string
+		MaxFileSize    int
+		MaxAge         int
+		MaxBackups     int
+		LocalTime      bool
+		Compress       bool
+		Filename       string
+		Stdout         bool
+		Instance       string
+		Encoder        string
+		TimeFormat     string
+		Caller         bool
+		Stacktrace     bool
+		Level          string
+		ServiceLevels  map[string]string
+		Sinks          []Sink
+		RedactKeys     []string
+		RedactPatterns []string
+		Sampling       *Sampling
+		Dedup          time.Duration
+		MaxTotalSize   int
+		ArchiveDir     string
+		RotateHooks    []RotateHook
+		validated      bool
 	}
 )
 
 func NewDefaultConfig() *Config {
 	return &Config{
-		LogDir:      "",
-		ServiceName: defaultName,
-		Instance:    defaultInstance,
-		MaxFileSize: defaultMaxFileSize,
-		MaxAge:      defaultMaxAge,
-		MaxBackups:  defaultMaxBackups,
-		LocalTime:   defaultLocalTime,
-		Compress:    defaultCompress,
-		Stdout:      defaultStdout,
-		Filename:    fmt.Sprintf("%s-%s.log", defaultName, defaultInstance),
+		LogDir:         "",
+		ServiceName:    defaultName,
+		Instance:       defaultInstance,
+		MaxFileSize:    defaultMaxFileSize,
+		MaxAge:         defaultMaxAge,
+		MaxBackups:     defaultMaxBackups,
+		LocalTime:      defaultLocalTime,
+		Compress:       defaultCompress,
+		Stdout:         defaultStdout,
+		Encoder:        defaultEncoder,
+		TimeFormat:     defaultTimeFormat,
+		Level:          defaultLevel,
+		RedactKeys:     append([]string{}, DefaultRedactKeys...),
+		RedactPatterns: append([]string{}, DefaultRedactPatterns...),
+		Filename:       fmt.Sprintf("%s-%s.log", defaultName, defaultInstance),
 	}
 }
 
 func (c *Config) SetPath(logDir, name, instance string) error {
-	if logDir == "" {
+	if logDir != "" {
 		c.LogDir = logDir
 	}
 
@@ -59,6 +82,7 @@ func (c *Config) SetPath(logDir, name, instance string) error {
 	if instance != "" {
 		c.Instance = instance
 	}
+	c.Filename = fmt.Sprintf("%s-%s.log", c.ServiceName, c.Instance)
 	c.Stdout = false
 
 	return c.Validate()
@@ -72,9 +96,101 @@ func (c *Config) SetRotation(maxFileSize int, maxAge int, maxBackups int, localT
 	c.Compress = compress
 }
 
+// SetFormat sets the encoder (console, json or logfmt) and the time format, a named format like
+// iso8601, rfc3339, rfc3339nano, epoch and millis or a go time layout, empty values keep the current ones.
+// caller adds the file and line of the log call and stacktrace adds a stack trace to error entries.
+func (c *Config) SetFormat(encoder, timeFormat string, caller, stacktrace bool) error {
+	if encoder != "" {
+		c.Encoder = strings.ToLower(encoder)
+	}
+
+	if timeFormat != "" {
+		c.TimeFormat = timeFormat
+	}
+
+	c.Caller = caller
+	c.Stacktrace = stacktrace
+
+	return c.validateEncoder()
+}
+
+// SetLevel sets the global level and the per-service level overrides, an empty level keeps the current one
+func (c *Config) SetLevel(level string, services map[string]string) error {
+	if level != "" {
+		c.Level = level
+	}
+
+	if len(services) > 0 {
+		c.ServiceLevels = services
+	}
+
+	return c.validateLevel()
+}
+
+// AddSink adds a destination of the log entries, once a sink is added the entries are written only to
+// the configured sinks instead of stdout or the log file
+func (c *Config) AddSink(sink Sink) error {
+	if err := sink.validate(); err != nil {
+		return err
+	}
+
+	c.Sinks = append(c.Sinks, sink)
+	return nil
+}
+
+// SetRedaction adds field names whose values are masked and regular expressions whose matches are masked
+// in the messages and string values, the defaults are kept
+func (c *Config) SetRedaction(keys, patterns []string) error {
+	c.RedactKeys = append(c.RedactKeys, keys...)
+	c.RedactPatterns = append(c.RedactPatterns, patterns...)
+
+	_, err := newRedactor(c.RedactKeys, c.RedactPatterns)
+	return err
+}
+
+func (c *Config) validateLevel() error {
+	if _, err := ParseLevel(c.Level); err != nil {
+		return err
+	}
+
+	for _, level := range c.ServiceLevels {
+		if _, err := ParseLevel(level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateEncoder() error {
+	switch c.Encoder {
+	case "", EncoderAuto, EncoderConsole, EncoderJSON, EncoderLogfmt, EncoderPretty:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrorInvalidEncoder, c.Encoder)
+	}
+}
+
 func (c *Config) Validate() error {
 	c.validated = true
 
+	if err := c.validateEncoder(); err != nil {
+		return err
+	}
+
+	if err := c.validateLevel(); err != nil {
+		return err
+	}
+
+	if _, err := newRedactor(c.RedactKeys, c.RedactPatterns); err != nil {
+		return err
+	}
+
+	for i := range c.Sinks {
+		if err := c.Sinks[i].validate(); err != nil {
+			return err
+		}
+	}
+
 	if c.LogDir == "" {
 		currPath, err := filepath.Abs(".")
 		if err != nil {
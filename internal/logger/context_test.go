@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+
+	assert.Equal(t, root, FromContext(context.Background()))
+
+	ctx := NewContext(context.Background(), Service("api", "instance", "local", "run_id", "01H"))
+	FromContext(ctx).Info("started")
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]any{
+		"service":  "api",
+		"instance": "local",
+		"run_id":   "01H",
+	}, logs.All()[0].ContextMap())
+}
+
+func TestServiceFileLogger(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := NewDefaultConfig()
+	assert.Nil(t, cfg.SetFormat(EncoderJSON, "", false, false))
+	assert.Nil(t, cfg.SetPath(dir, "", "test"))
+
+	l, err := NewServiceFileLogger(cfg, "main service", "run_id", "01H")
+	assert.Nil(t, err)
+
+	l.With("k", "v").Info("started")
+
+	data, err := os.ReadFile(filepath.Join(dir, "main-service-test.log"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"msg":"started","service":"main service","run_id":"01H","k":"v"`)
+}
+
+func TestServiceFileLoggerSinks(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := NewDefaultConfig()
+	assert.Nil(t, cfg.SetFormat(EncoderJSON, "", false, false))
+	assert.Nil(t, cfg.SetPath(dir, "", "test"))
+	assert.Nil(t, cfg.AddSink(Sink{Type: SinkStderr}))
+	assert.Nil(t, cfg.AddSink(Sink{Type: SinkFile, Level: "warn"}))
+
+	l, err := NewServiceFileLogger(cfg, "main service")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	l.Info("not written")
+	l.Warn("slow request")
+
+	// the service file is the only destination, with the level of the file sink
+	assert.Len(t, l.cores, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, "main-service-test.log"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"msg":"slow request"`)
+	assert.NotContains(t, string(data), "not written")
+
+	// without a file sink the service file is written with the config settings
+	cfg.Sinks = []Sink{{Type: SinkStderr}}
+
+	worker, err := NewServiceFileLogger(cfg, "worker")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = worker.Close()
+	})
+
+	worker.Info("started")
+
+	data, err = os.ReadFile(filepath.Join(dir, "worker-test.log"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"msg":"started"`)
+}
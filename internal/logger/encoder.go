@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strconv"
+	"strings"
+)
+
+const (
+	EncoderAuto    = "auto"
+	EncoderConsole = "console"
+	EncoderJSON    = "json"
+	EncoderLogfmt  = "logfmt"
+	EncoderPretty  = "pretty"
+
+	TimeFormatISO8601     = "iso8601"
+	TimeFormatRFC3339     = "rfc3339"
+	TimeFormatRFC3339Nano = "rfc3339nano"
+	TimeFormatEpoch       = "epoch"
+	TimeFormatEpochMillis = "millis"
+)
+
+var (
+	ErrorInvalidEncoder = fmt.Errorf("logger: invalid encoder, use %s, %s, %s, %s or %s", EncoderAuto, EncoderConsole, EncoderJSON, EncoderLogfmt, EncoderPretty)
+	logfmtPool          = buffer.NewPool()
+)
+
+type (
+	// logfmtEncoder writes entries as logfmt key=value pairs, the entry is encoded by the json encoder
+	// and flattened so it supports every field type the json encoder does
+	logfmtEncoder struct {
+		zapcore.Encoder
+	}
+)
+
+// newEncoder creates the encoder selected in the config
+func newEncoder(cfg *Config) (zapcore.Encoder, error) {
+	return buildEncoder(cfg.Encoder, cfg.TimeFormat, nil)
+}
+
+// buildEncoder creates an encoder by name with the time format, the auto encoder is pretty when
+// renderer writes to an interactive terminal and console otherwise
+func buildEncoder(encoder, timeFormat string, renderer *lipgloss.Renderer) (zapcore.Encoder, error) {
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = timeEncoder(timeFormat)
+
+	if encoder == EncoderAuto {
+		encoder = EncoderConsole
+		if interactive(renderer) {
+			encoder = EncoderPretty
+		}
+	}
+
+	switch encoder {
+	case EncoderPretty:
+		return newPrettyEncoder(renderer), nil
+	case "", EncoderConsole:
+		return zapcore.NewConsoleEncoder(encoderCfg), nil
+	case EncoderJSON:
+		return zapcore.NewJSONEncoder(encoderCfg), nil
+	case EncoderLogfmt:
+		return &logfmtEncoder{Encoder: zapcore.NewJSONEncoder(encoderCfg)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidEncoder, encoder)
+	}
+}
+
+// timeEncoder returns the encoder of a named time format, any other value is used as a time layout
+func timeEncoder(format string) zapcore.TimeEncoder {
+	switch strings.ToLower(format) {
+	case "", TimeFormatISO8601:
+		return zapcore.ISO8601TimeEncoder
+	case TimeFormatRFC3339:
+		return zapcore.RFC3339TimeEncoder
+	case TimeFormatRFC3339Nano:
+		return zapcore.RFC3339NanoTimeEncoder
+	case TimeFormatEpoch:
+		return zapcore.EpochTimeEncoder
+	case TimeFormatEpochMillis:
+		return zapcore.EpochMillisTimeEncoder
+	default:
+		return zapcore.TimeEncoderOfLayout(format)
+	}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	dec := json.NewDecoder(bytes.NewReader(encoded.Bytes()))
+	dec.UseNumber()
+
+	buf := logfmtPool.Get()
+	if err := writeLogfmtObject(dec, buf, "", nil); err != nil {
+		buf.Free()
+		return nil, err
+	}
+
+	buf.AppendByte('\n')
+	return buf, nil
+}
+
+// writeLogfmtObject writes the next json object of dec as key=value pairs, nested keys are joined with a dot
+// and styled by styleKey when it is set
+func writeLogfmtObject(dec *json.Decoder, buf *buffer.Buffer, prefix string, styleKey func(string) string) error {
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key := prefix + token.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		if len(raw) > 0 && raw[0] == '{' {
+			nested := json.NewDecoder(bytes.NewReader(raw))
+			nested.UseNumber()
+			if err := writeLogfmtObject(nested, buf, key+".", styleKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.AppendByte(' ')
+		}
+		if styleKey != nil {
+			buf.AppendString(styleKey(key))
+		} else {
+			buf.AppendString(key)
+		}
+		buf.AppendByte('=')
+		buf.AppendString(logfmtValue(raw))
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// logfmtValue formats a json value, strings are quoted only when needed
+func logfmtValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		// numbers, booleans, null and arrays are written as they are encoded
+		s = string(raw)
+		if strings.ContainsAny(s, " =\"") {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
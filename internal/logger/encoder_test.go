@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encode writes a single entry with the encoder of cfg
+func encode(t *testing.T, cfg *Config, fields ...zap.Field) string {
+	encoder, err := newEncoder(cfg)
+	assert.Nil(t, err)
+
+	entry := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2023, 11, 1, 10, 0, 0, 0, time.UTC),
+		Message: "request done",
+	}
+
+	buf, err := encoder.EncodeEntry(entry, fields)
+	assert.Nil(t, err)
+	return buf.String()
+}
+
+func TestEncoders(t *testing.T) {
+	cfg := NewDefaultConfig()
+
+	assert.Nil(t, cfg.SetFormat(EncoderJSON, TimeFormatRFC3339, false, false))
+	assert.Equal(t, `{"level":"info","ts":"2023-11-01T10:00:00Z","msg":"request done","status":200}`+"\n",
+		encode(t, cfg, zap.Int("status", 200)))
+
+	assert.Nil(t, cfg.SetFormat(EncoderLogfmt, "2006-01-02", false, false))
+	assert.Equal(t, `level=info ts=2023-11-01 msg="request done" status=200 http.path=/status`+"\n",
+		encode(t, cfg, zap.Int("status", 200), zap.Namespace("http"), zap.String("path", "/status")))
+
+	assert.Nil(t, cfg.SetFormat(EncoderConsole, TimeFormatISO8601, false, false))
+	assert.True(t, strings.HasPrefix(encode(t, cfg), "2023-11-01T10:00:00.000Z\tinfo\trequest done"))
+
+	assert.ErrorIs(t, cfg.SetFormat("xml", "", false, false), ErrorInvalidEncoder)
+}
+
+func TestCallerAndStacktrace(t *testing.T) {
+	cfg := NewDefaultConfig()
+	assert.Nil(t, cfg.SetFormat(EncoderJSON, "", true, true))
+
+	var out bytes.Buffer
+	encoder, err := newEncoder(cfg)
+	assert.Nil(t, err)
+
+	previous := zapLogger
+	zapLogger = zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&out), zapcore.InfoLevel), zapOptions(cfg)...)
+	t.Cleanup(func() {
+		zapLogger = previous
+	})
+
+	Error("failed %d", 1)
+	With("k", "v").Info("done")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"caller":"logger/encoder_test.go:`)
+	assert.Contains(t, lines[0], `"stacktrace":`)
+	assert.Contains(t, lines[1], `"caller":"logger/encoder_test.go:`)
+	assert.NotContains(t, lines[1], `"stacktrace":`)
+}
+
+func TestPrettyEncoder(t *testing.T) {
+	renderer := lipgloss.NewRenderer(io.Discard)
+	renderer.SetColorProfile(termenv.ANSI)
+
+	encoder, err := buildEncoder(EncoderAuto, "", renderer)
+	assert.Nil(t, err)
+	assert.IsType(t, &prettyEncoder{}, encoder)
+
+	entry := zapcore.Entry{Level: zapcore.WarnLevel, Message: "slow request"}
+	buf, err := encoder.EncodeEntry(entry, []zap.Field{zap.String("path", "/status")})
+	assert.Nil(t, err)
+
+	style := renderer.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+	assert.Equal(t, style.Render("  •")+" slow request"+strings.Repeat(" ", prettyMessageWidth-len("slow request"))+
+		" "+style.Render("path")+"=/status\n", buf.String())
+
+	buf, err = encoder.EncodeEntry(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"}, nil)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "⨯")
+	assert.True(t, strings.HasSuffix(buf.String(), " failed\n"))
+
+	renderer.SetColorProfile(termenv.Ascii)
+	encoder, err = buildEncoder(EncoderAuto, "", renderer)
+	assert.Nil(t, err)
+	assert.IsType(t, zapcore.NewConsoleEncoder(zap.NewProductionEncoderConfig()), encoder)
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+)
+
+const defaultLevel = "info"
+
+var (
+	ErrorInvalidLevel = fmt.Errorf("logger: invalid level, use debug, info, warn, error, dpanic, panic or fatal")
+	levels            = newLevelSet()
+)
+
+type (
+	// levelSet holds the global level and the per-service overrides, the zap core is enabled at the lowest
+	// of them and every logger checks its own effective level
+	levelSet struct {
+		global   zap.AtomicLevel
+		mutex    sync.RWMutex
+		services map[string]zapcore.Level
+		minimum  zap.AtomicLevel
+	}
+
+	// Levels is the current global level and the per-service overrides
+	Levels struct {
+		Level    string            `json:"level"`
+		Services map[string]string `json:"services,omitempty"`
+	}
+)
+
+func newLevelSet() *levelSet {
+	return &levelSet{
+		global:   zap.NewAtomicLevel(),
+		services: make(map[string]zapcore.Level),
+		minimum:  zap.NewAtomicLevel(),
+	}
+}
+
+// ParseLevel parses a level name like debug, info, warn or error
+func ParseLevel(level string) (zapcore.Level, error) {
+	parsed, err := zapcore.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return parsed, fmt.Errorf("%w: %s", ErrorInvalidLevel, level)
+	}
+	return parsed, nil
+}
+
+// SetLevel changes the global level at runtime
+func SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levels.mutex.Lock()
+	defer levels.mutex.Unlock()
+
+	levels.global.SetLevel(parsed)
+	levels.updateMinimum()
+	return nil
+}
+
+// SetServiceLevel overrides the level of the loggers of a service at runtime, an empty level removes the override
+func SetServiceLevel(service, level string) error {
+	if level == "" {
+		levels.mutex.Lock()
+		defer levels.mutex.Unlock()
+
+		delete(levels.services, service)
+		levels.updateMinimum()
+		return nil
+	}
+
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levels.mutex.Lock()
+	defer levels.mutex.Unlock()
+
+	levels.services[service] = parsed
+	levels.updateMinimum()
+	return nil
+}
+
+// SetLevels replaces the global level and all the per-service overrides
+func SetLevels(l *Levels) error {
+	global, err := ParseLevel(l.Level)
+	if err != nil {
+		return err
+	}
+
+	services := make(map[string]zapcore.Level, len(l.Services))
+	for name, level := range l.Services {
+		if services[name], err = ParseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	levels.mutex.Lock()
+	defer levels.mutex.Unlock()
+
+	levels.global.SetLevel(global)
+	levels.services = services
+	levels.updateMinimum()
+	return nil
+}
+
+// GetLevels returns the global level and the per-service overrides
+func GetLevels() *Levels {
+	levels.mutex.RLock()
+	defer levels.mutex.RUnlock()
+
+	l := &Levels{
+		Level:    levels.global.Level().String(),
+		Services: make(map[string]string, len(levels.services)),
+	}
+
+	for name, level := range levels.services {
+		l.Services[name] = level.String()
+	}
+	return l
+}
+
+// updateMinimum sets the core level to the lowest configured level, the mutex must be held
+func (s *levelSet) updateMinimum() {
+	minimum := s.global.Level()
+	for _, level := range s.services {
+		if level < minimum {
+			minimum = level
+		}
+	}
+	s.minimum.SetLevel(minimum)
+}
+
+// enabled reports whether an entry of the service is logged at level, an empty service uses the global level
+func (s *levelSet) enabled(service string, level zapcore.Level) bool {
+	if !s.minimum.Enabled(level) {
+		return false
+	}
+
+	if service != "" {
+		s.mutex.RLock()
+		override, ok := s.services[service]
+		s.mutex.RUnlock()
+
+		if ok {
+			return override.Enabled(level)
+		}
+	}
+	return s.global.Enabled(level)
+}
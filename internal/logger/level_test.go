@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	logs := observe(t, zapcore.DebugLevel)
+	t.Cleanup(func() {
+		_ = SetLevels(&Levels{Level: defaultLevel})
+	})
+
+	api := Service("api")
+	worker := Service("worker")
+
+	Debug("hidden")
+	api.Debug("hidden")
+	assert.Equal(t, 0, logs.Len())
+
+	assert.Nil(t, SetServiceLevel("api", "debug"))
+	Debug("hidden")
+	api.With("k", "v").Debug("api debug")
+	worker.Debug("hidden")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "api debug", logs.All()[0].Message)
+	assert.Equal(t, "api", logs.All()[0].ContextMap()["service"])
+
+	assert.Nil(t, SetLevel("warn"))
+	Info("hidden")
+	worker.Info("hidden")
+	api.Info("api info")
+	assert.Equal(t, 2, logs.Len())
+
+	assert.Equal(t, &Levels{Level: "warn", Services: map[string]string{"api": "debug"}}, GetLevels())
+
+	assert.Nil(t, SetServiceLevel("api", ""))
+	api.Info("hidden")
+	assert.Equal(t, 2, logs.Len())
+
+	assert.ErrorIs(t, SetLevel("verbose"), ErrorInvalidLevel)
+	assert.ErrorIs(t, SetLevels(&Levels{Level: "info", Services: map[string]string{"api": "loud"}}), ErrorInvalidLevel)
+	assert.Equal(t, "warn", GetLevels().Level)
+}
+
+func TestConfigLevel(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SetLevels(&Levels{Level: defaultLevel})
+	})
+
+	cfg := NewDefaultConfig()
+	assert.ErrorIs(t, cfg.SetLevel("verbose", nil), ErrorInvalidLevel)
+	assert.Nil(t, cfg.SetLevel("debug", map[string]string{"api": "error"}))
+
+	assert.Nil(t, NewLogger(cfg))
+	assert.Equal(t, &Levels{Level: "debug", Services: map[string]string{"api": "error"}}, GetLevels())
+	assert.True(t, zapLogger.Core().Enabled(zapcore.DebugLevel))
+}
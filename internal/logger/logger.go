@@ -2,106 +2,214 @@ package logger
 
 import (
 	"fmt"
-	"github.com/caarlos0/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
-	"os"
-	"path/filepath"
+	"strings"
 )
 
+// callerSkip is the number of frames between the log call and the zap logger: the exported function
+// and Logger.log
+const callerSkip = 2
+
 var (
 	ErrorLoggerConfigNotValidated = fmt.Errorf("logger config not validated")
 	zapLogger                     *zap.Logger
+	zapCores                      []zapcore.Core // the sink cores of the global logger, closed when it is replaced
+	root                          = &Logger{}
+	fileNameReplacer              = strings.NewReplacer(" ", "-", "/", "-", "\\", "-")
 )
 
 type (
 	ZapLogger struct{}
 )
 
+func init() {
+	// entries written before the logger is configured go to stderr, so they do not mix with the output
+	// of the commands
+	cfg := NewDefaultConfig()
+	cfg.Sinks = []Sink{{Type: SinkStderr}}
+	zapLogger, zapCores, _, _ = newZapLogger(cfg)
+}
+
 func NewLoggerDefault() error {
-	ll, err := initLoggerDefault()
+	ll, cores, destinations, err := initLoggerDefault()
 	if err != nil {
 		return err
 	}
 
-	zapLogger = ll
+	setLogger(ll, cores, destinations)
 	return nil
 }
 
 func NewLogger(cfg *Config) error {
-	ll, err := initLogger(cfg)
+	ll, cores, destinations, err := initLogger(cfg)
 	if err != nil {
 		return err
 	}
-	zapLogger = ll
+
+	setLogger(ll, cores, destinations)
 	return nil
 }
 
-func initLoggerDefault() (*zap.Logger, error) {
+// setLogger replaces the global logger, closes the sinks of the previous one and reports where it writes
+func setLogger(ll *zap.Logger, cores []zapcore.Core, destinations []string) {
+	_ = zapLogger.Sync()
+	previous := zapCores
+	zapLogger, zapCores = ll, cores
+	closeCores(previous)
+
+	for _, destination := range destinations {
+		Info("using logger to %s", destination)
+	}
+}
+
+// NewServiceFileLogger returns a logger of a service writing to its own file, named after the service and
+// the instance, in the log directory of cfg with the same format and rotation settings. The other sinks of
+// cfg are left to the global logger, only the level and encoder of its file sink are kept
+func NewServiceFileLogger(cfg *Config, name string, fields ...any) (*Logger, error) {
+	serviceCfg := *cfg
+	serviceCfg.Sinks = []Sink{{Type: SinkFile}}
+	for _, sink := range cfg.Sinks {
+		if sink.Type == SinkFile {
+			serviceCfg.Sinks[0] = sink
+			break
+		}
+	}
+
+	if err := serviceCfg.SetPath(cfg.LogDir, fileNameReplacer.Replace(name), cfg.Instance); err != nil {
+		return nil, err
+	}
+
+	ll, cores, destinations, err := newZapLogger(&serviceCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := Service(name, fields...)
+	l.zl = ll
+	l.cores = cores
+
+	for _, destination := range destinations {
+		l.Info(fmt.Sprintf("using logger to %s", destination))
+	}
+	return l, nil
+}
+
+func initLoggerDefault() (*zap.Logger, []zapcore.Core, []string, error) {
 	cfg := NewDefaultConfig()
 	return initLogger(cfg)
 }
 
-func initLogger(cfg *Config) (*zap.Logger, error) {
-	defer func() {
-		cfg = nil // clean global config instance
-	}()
+func initLogger(cfg *Config) (*zap.Logger, []zapcore.Core, []string, error) {
+	ll, cores, destinations, err := newZapLogger(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	writeSyncer := zapcore.AddSync(os.Stdout)
+	if err := SetLevels(&Levels{Level: cfg.Level, Services: cfg.ServiceLevels}); err != nil {
+		closeCores(cores)
+		return nil, nil, nil, err
+	}
+	return ll, cores, destinations, nil
+}
 
-	encoderCfg := zap.NewProductionEncoderConfig()
-	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+// newZapLogger creates the logger writing to the sinks of cfg, its sink cores to close them and the
+// descriptions of the sinks
+func newZapLogger(cfg *Config) (*zap.Logger, []zapcore.Core, []string, error) {
+	r, err := newRedactor(cfg.RedactKeys, cfg.RedactPatterns)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	if !cfg.Stdout {
-		if !cfg.validated {
-			return nil, ErrorLoggerConfigNotValidated
+	sinks := cfg.sinks()
+	cores := make([]zapcore.Core, 0, len(sinks))
+	destinations := make([]string, 0, len(sinks))
+
+	for _, sink := range sinks {
+		core, destination, err := newSinkCore(cfg, sink)
+		if err != nil {
+			closeCores(cores)
+			return nil, nil, nil, err
 		}
 
-		if _, err := os.Stat(cfg.LogDir); os.IsNotExist(err) {
-			if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
-				log.Infof("failed to create log directory: %s", cfg.LogDir)
-				os.Exit(1)
-			}
+		// every sink core is wrapped so no sink receives the entries before they are redacted
+		if r != nil {
+			core = &redactCore{Core: core, r: r}
 		}
+		cores = append(cores, core)
+		destinations = append(destinations, destination)
+	}
 
-		writeSyncer = zapcore.AddSync(&lumberjack.Logger{
-			Filename:   filepath.Join(cfg.LogDir, cfg.Filename),
-			MaxSize:    cfg.MaxFileSize,
-			MaxBackups: cfg.MaxBackups,
-			LocalTime:  cfg.LocalTime,
-			Compress:   cfg.Compress,
-			MaxAge:     cfg.MaxAge,
-		})
+	// the cores are enabled at the lowest configured level, each logger filters at its effective level
+	return zap.New(wrapCore(cfg, zapcore.NewTee(cores...)), zapOptions(cfg)...), cores, destinations, nil
+}
 
-		log.Infof("using logger to file: %s", filepath.Join(cfg.LogDir, cfg.Filename))
+// redact masks the sensitive values of s with the redaction settings of the global logger
+func redact(s string) string {
+	for _, core := range zapCores {
+		if rc, ok := core.(*redactCore); ok {
+			return rc.r.redactString(s)
+		}
 	}
+	return s
+}
 
-	if cfg.Stdout {
-		log.Info("using logger to stdout")
+// closeCores closes the sockets and the rotating files of the cores of a replaced logger or of the cores
+// created before a sink failed
+func closeCores(cores []zapcore.Core) {
+	for _, core := range cores {
+		if rc, ok := core.(*redactCore); ok {
+			core = rc.Core
+		}
+
+		switch c := core.(type) {
+		case *socketCore:
+			_ = c.conn.Close()
+		case *rotatingCore:
+			_ = c.w.Close()
+		}
+	}
+}
+
+// zapOptions returns the logger options for the caller and stack trace settings
+func zapOptions(cfg *Config) []zap.Option {
+	opts := make([]zap.Option, 0, 3)
+
+	if cfg.Caller {
+		// skip the package functions between the caller and the zap logger
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(callerSkip))
 	}
 
-	return zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), writeSyncer, zapcore.InfoLevel)), nil
+	if cfg.Stacktrace {
+		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	return opts
+}
+
+// Sync flushes the entries buffered by the global logger, like the pending dedup summaries
+func Sync() error {
+	return zapLogger.Sync()
 }
 
 func Info(format string, args ...any) {
-	zapLogger.Info(fmt.Sprintf(format, args...))
+	root.log(zapcore.InfoLevel, fmt.Sprintf(format, args...), nil)
 }
 
+// InfoAndPrint logs the message and prints it to stdout, redacted like the logged one
 func InfoAndPrint(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
-	zapLogger.Info(msg)
-	fmt.Println(msg)
+	root.log(zapcore.InfoLevel, msg, nil)
+	fmt.Println(redact(msg))
 }
 
 func Error(format string, args ...any) {
-	zapLogger.Error(fmt.Sprintf(format, args...))
+	root.log(zapcore.ErrorLevel, fmt.Sprintf(format, args...), nil)
 }
 
 func Debug(format string, args ...any) {
-	zapLogger.Debug(fmt.Sprintf(format, args...))
+	root.log(zapcore.DebugLevel, fmt.Sprintf(format, args...), nil)
 }
 
 func Warn(format string, args ...any) {
-	zapLogger.Warn(fmt.Sprintf(format, args...))
+	root.log(zapcore.WarnLevel, fmt.Sprintf(format, args...), nil)
 }
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// prettyMessageWidth is the column where the fields start when the entry has fields
+const prettyMessageWidth = 48
+
+var (
+	prettyColors = map[zapcore.Level]string{
+		zapcore.DebugLevel: "15",
+		zapcore.InfoLevel:  "12",
+		zapcore.WarnLevel:  "11",
+	}
+
+	prettySymbols = map[zapcore.Level]string{
+		zapcore.DebugLevel: "•",
+		zapcore.InfoLevel:  "•",
+		zapcore.WarnLevel:  "•",
+	}
+)
+
+type (
+	// prettyEncoder writes colored entries for interactive terminals: a level symbol, the message and
+	// the fields as key=value pairs, the fields are encoded by the json encoder and flattened
+	prettyEncoder struct {
+		zapcore.Encoder
+		renderer *lipgloss.Renderer
+	}
+)
+
+// interactive reports whether renderer writes to a terminal supporting colors
+func interactive(renderer *lipgloss.Renderer) bool {
+	return renderer != nil && renderer.ColorProfile() != termenv.Ascii
+}
+
+func newPrettyEncoder(renderer *lipgloss.Renderer) *prettyEncoder {
+	if renderer == nil {
+		renderer = lipgloss.DefaultRenderer()
+	}
+
+	// only the fields, the caller and the stack trace are encoded as json
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = zapcore.OmitKey
+	encoderCfg.LevelKey = zapcore.OmitKey
+	encoderCfg.MessageKey = zapcore.OmitKey
+	encoderCfg.NameKey = zapcore.OmitKey
+
+	return &prettyEncoder{
+		Encoder:  zapcore.NewJSONEncoder(encoderCfg),
+		renderer: renderer,
+	}
+}
+
+// style returns the style of the level, error and above are red
+func (e *prettyEncoder) style(level zapcore.Level) lipgloss.Style {
+	color, ok := prettyColors[level]
+	if !ok {
+		color = "9"
+	}
+	return e.renderer.NewStyle().Foreground(lipgloss.Color(color)).Bold(true)
+}
+
+func (e *prettyEncoder) Clone() zapcore.Encoder {
+	return &prettyEncoder{
+		Encoder:  e.Encoder.Clone(),
+		renderer: e.renderer,
+	}
+}
+
+func (e *prettyEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	dec := json.NewDecoder(bytes.NewReader(encoded.Bytes()))
+	dec.UseNumber()
+
+	style := e.style(entry.Level)
+
+	pairs := logfmtPool.Get()
+	defer pairs.Free()
+
+	if err := writeLogfmtObject(dec, pairs, "", func(key string) string {
+		return style.Render(key)
+	}); err != nil {
+		return nil, err
+	}
+
+	symbol, ok := prettySymbols[entry.Level]
+	if !ok {
+		symbol = "⨯"
+	}
+
+	buf := logfmtPool.Get()
+	buf.AppendString(style.Render(fmt.Sprintf("%3s", symbol)))
+	buf.AppendByte(' ')
+	buf.AppendString(entry.Message)
+
+	if pairs.Len() > 0 {
+		if pad := prettyMessageWidth - len(entry.Message); pad > 0 {
+			buf.AppendString(strings.Repeat(" ", pad))
+		}
+		buf.AppendByte(' ')
+		buf.AppendString(pairs.String())
+	}
+
+	buf.AppendByte('\n')
+	return buf, nil
+}
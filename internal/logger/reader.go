@@ -0,0 +1,442 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// followInterval is how often a followed file is checked for new lines
+const followInterval = 250 * time.Millisecond
+
+var timeLayouts = []string{
+	"2006-01-02T15:04:05.000Z0700",
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+}
+
+type (
+	// Filter selects the log lines written by ReadLogs and FollowLog, lines that are not structured
+	// only pass an empty filter
+	Filter struct {
+		Since   time.Time
+		Level   *zapcore.Level
+		Service string
+	}
+
+	// Record is the parsed header of a structured log line, written by the json, logfmt or console encoders
+	Record struct {
+		Time    time.Time
+		Level   zapcore.Level
+		Service string
+	}
+)
+
+// LogFiles returns the log files of dir, the rotated files of each logger by age followed by its current file
+func LogFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]string, 0)
+	rotated := make(map[string][]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, compressSuffix)
+		if filepath.Ext(base) != ".log" {
+			continue
+		}
+
+		if owner, ok := backupOwner(base); ok {
+			rotated[owner] = append(rotated[owner], name)
+			continue
+		}
+		current = append(current, name)
+	}
+
+	sort.Strings(current)
+
+	files := make([]string, 0, len(entries))
+	for _, name := range current {
+		backups := rotated[name]
+		sort.Strings(backups)
+		delete(rotated, name)
+
+		for _, backup := range backups {
+			files = append(files, filepath.Join(dir, backup))
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	// rotated files whose current file was removed
+	owners := make([]string, 0, len(rotated))
+	for owner := range rotated {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		backups := rotated[owner]
+		sort.Strings(backups)
+		for _, backup := range backups {
+			files = append(files, filepath.Join(dir, backup))
+		}
+	}
+	return files, nil
+}
+
+// backupOwner returns the name of the current file of a rotated file name, like app-<time stamp>.log
+func backupOwner(name string) (string, bool) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	if len(stem) <= len(backupTimeFormat)+1 {
+		return "", false
+	}
+
+	stamp := stem[len(stem)-len(backupTimeFormat):]
+	if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+		return "", false
+	}
+	return stem[:len(stem)-len(backupTimeFormat)-1] + ext, true
+}
+
+// ReadLogs writes the lines of the files matching filter to w, gzip compressed files are decompressed.
+// It returns the offsets the files were read to, FollowLog continues from them
+func ReadLogs(ctx context.Context, files []string, filter *Filter, w io.Writer) (map[string]int64, error) {
+	offsets := make(map[string]int64, len(files))
+
+	for _, path := range files {
+		offset, err := readLogFile(ctx, path, filter, w)
+		if err != nil {
+			return nil, err
+		}
+		offsets[path] = offset
+	}
+	return offsets, nil
+}
+
+func readLogFile(ctx context.Context, path string, filter *Filter, w io.Writer) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, compressSuffix) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var partial []byte
+	offset, err := copyLines(ctx, bufio.NewReader(r), &partial, filter, w)
+	if err != nil {
+		return offset, err
+	}
+
+	// a last line without new line
+	if len(partial) > 0 && filter.Match(partial) {
+		_, err = w.Write(append(partial, '\n'))
+	}
+	return offset, err
+}
+
+// FollowLog writes the lines appended to path matching filter until ctx is done, starting at offset, like
+// the one returned by ReadLogs, or at the start of the file when it is smaller. When the file is rotated
+// the rest of the previous one is written before the new one is read from the start
+func FollowLog(ctx context.Context, path string, offset int64, filter *Filter, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if info, err := f.Stat(); err != nil {
+		return err
+	} else if info.Size() < offset {
+		offset = 0
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var partial []byte
+	reader := bufio.NewReader(f)
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := copyLines(ctx, reader, &partial, filter, w)
+		if err != nil {
+			return err
+		}
+		offset += n
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// a file smaller than what was read, or a different file, means it was rotated
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		current, err := f.Stat()
+		if err == nil && os.SameFile(info, current) && info.Size() >= offset {
+			continue
+		}
+
+		rotated, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		// the lines written to the previous file before it was rotated
+		if _, err := copyLines(ctx, reader, &partial, filter, w); err != nil {
+			_ = rotated.Close()
+			return err
+		}
+
+		if len(partial) > 0 && filter.Match(partial) {
+			if _, err := w.Write(append(partial, '\n')); err != nil {
+				_ = rotated.Close()
+				return err
+			}
+		}
+
+		_ = f.Close()
+		f, offset, partial = rotated, 0, nil
+		reader.Reset(f)
+	}
+}
+
+// copyLines writes the complete lines of r matching filter to w, it returns the bytes read,
+// an incomplete last line is kept in partial and completed by the next call
+func copyLines(ctx context.Context, r *bufio.Reader, partial *[]byte, filter *Filter, w io.Writer) (int64, error) {
+	var consumed int64
+
+	for ctx.Err() == nil {
+		line, err := r.ReadBytes('\n')
+		consumed += int64(len(line))
+
+		if err == io.EOF {
+			*partial = append(*partial, line...)
+			return consumed, nil
+		}
+
+		if err != nil {
+			return consumed, err
+		}
+
+		if len(*partial) > 0 {
+			line = append(*partial, line...)
+			*partial = nil
+		}
+
+		if filter.Match(line) {
+			if _, err := w.Write(line); err != nil {
+				return consumed, err
+			}
+		}
+	}
+	return consumed, nil
+}
+
+// empty reports whether the filter selects every line
+func (f *Filter) empty() bool {
+	return f == nil || (f.Since.IsZero() && f.Level == nil && f.Service == "")
+}
+
+// Match reports whether a log line is selected by the filter
+func (f *Filter) Match(line []byte) bool {
+	if f.empty() {
+		return true
+	}
+
+	record, ok := ParseRecord(string(line))
+	if !ok {
+		return false
+	}
+
+	if !f.Since.IsZero() && (record.Time.IsZero() || record.Time.Before(f.Since)) {
+		return false
+	}
+
+	if f.Level != nil && record.Level < *f.Level {
+		return false
+	}
+
+	return f.Service == "" || record.Service == f.Service
+}
+
+// ParseRecord parses the time, level and service of a line written by the json, logfmt or console encoders
+func ParseRecord(line string) (*Record, bool) {
+	line = strings.TrimRight(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSONRecord(line)
+	case strings.HasPrefix(line, "level=") || strings.HasPrefix(line, "ts="):
+		return parseLogfmtRecord(line)
+	default:
+		return parseConsoleRecord(line)
+	}
+}
+
+func parseJSONRecord(line string) (*Record, bool) {
+	fields := make(map[string]any)
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return nil, false
+	}
+
+	level, ok := fields["level"].(string)
+	if !ok {
+		return nil, false
+	}
+
+	record := &Record{}
+	if err := record.Level.UnmarshalText([]byte(level)); err != nil {
+		return nil, false
+	}
+
+	record.Time = parseRecordTime(fields["ts"])
+	record.Service, _ = fields["service"].(string)
+	return record, true
+}
+
+func parseLogfmtRecord(line string) (*Record, bool) {
+	fields := parseLogfmt(line)
+
+	record := &Record{}
+	if err := record.Level.UnmarshalText([]byte(fields["level"])); err != nil || fields["level"] == "" {
+		return nil, false
+	}
+
+	record.Time = parseRecordTime(fields["ts"])
+	record.Service = fields["service"]
+	return record, true
+}
+
+// parseConsoleRecord parses the tab separated time, level, [caller,] message and json fields
+func parseConsoleRecord(line string) (*Record, bool) {
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		return nil, false
+	}
+
+	record := &Record{}
+	if err := record.Level.UnmarshalText([]byte(parts[1])); err != nil {
+		return nil, false
+	}
+
+	record.Time = parseRecordTime(parts[0])
+
+	if last := parts[len(parts)-1]; strings.HasPrefix(last, "{") {
+		fields := make(map[string]any)
+		if err := json.Unmarshal([]byte(last), &fields); err == nil {
+			record.Service, _ = fields["service"].(string)
+		}
+	}
+	return record, true
+}
+
+// parseLogfmt splits a logfmt line in its key value pairs, quoted values are unquoted
+func parseLogfmt(line string) map[string]string {
+	fields := make(map[string]string)
+
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			break
+		}
+
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := 1
+			for end < len(line) && (line[end] != '"' || line[end-1] == '\\') {
+				end++
+			}
+
+			quoted := line[:min(end+1, len(line))]
+			if unquoted, err := strconv.Unquote(quoted); err == nil {
+				value = unquoted
+			} else {
+				value = quoted
+			}
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+
+		fields[key] = value
+	}
+	return fields
+}
+
+// parseRecordTime parses the time encoded with the named time formats, epoch numbers are seconds or
+// milliseconds, a time that cannot be parsed is zero
+func parseRecordTime(value any) time.Time {
+	switch v := value.(type) {
+	case float64:
+		return epochTime(v)
+	case string:
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			return epochTime(number)
+		}
+
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// epochTime converts seconds, or milliseconds for values after 2001 in milliseconds, to a time
+func epochTime(value float64) time.Time {
+	if value > 1e12 {
+		value /= 1000
+	}
+
+	sec, frac := math.Modf(value)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func writeGzip(t *testing.T, path, content string) {
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, gz.Close())
+	assert.Nil(t, f.Close())
+}
+
+func TestLogFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writeGzip(t, filepath.Join(dir, "app-2024-01-01T10-00-00.000.log.gz"), "first\n")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "app-2024-01-02T10-00-00.000.log"), []byte("second\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "app.log"), []byte("third"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "old-2024-01-01T10-00-00.000.log"), []byte("orphan\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored\n"), 0644))
+
+	files, err := LogFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "app-2024-01-01T10-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2024-01-02T10-00-00.000.log"),
+		filepath.Join(dir, "app.log"),
+		filepath.Join(dir, "old-2024-01-01T10-00-00.000.log"),
+	}, files)
+
+	out := &bytes.Buffer{}
+	offsets, err := ReadLogs(context.Background(), files, nil, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "first\nsecond\nthird\norphan\n", out.String())
+	assert.Equal(t, int64(len("third")), offsets[filepath.Join(dir, "app.log")])
+}
+
+func TestParseRecord(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		line    string
+		record  *Record
+		matched bool
+	}{
+		{
+			name:    "json",
+			line:    `{"level":"warn","ts":"2024-01-02T10:00:00.000Z","msg":"m","service":"api"}`,
+			record:  &Record{Time: ts, Level: zapcore.WarnLevel, Service: "api"},
+			matched: true,
+		},
+		{
+			name:    "json epoch",
+			line:    `{"level":"info","ts":1704189600,"msg":"m"}`,
+			record:  &Record{Time: ts, Level: zapcore.InfoLevel},
+			matched: true,
+		},
+		{
+			name:    "logfmt",
+			line:    `level=error ts=2024-01-02T10:00:00Z msg="a message" service=worker`,
+			record:  &Record{Time: ts, Level: zapcore.ErrorLevel, Service: "worker"},
+			matched: true,
+		},
+		{
+			name:    "console",
+			line:    "2024-01-02T10:00:00.000Z\tdebug\tmessage\t{\"service\": \"api\"}",
+			record:  &Record{Time: ts, Level: zapcore.DebugLevel, Service: "api"},
+			matched: true,
+		},
+		{
+			name: "unstructured",
+			line: "plain text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, ok := ParseRecord(tt.line)
+			assert.Equal(t, tt.matched, ok)
+
+			if tt.matched {
+				assert.True(t, tt.record.Time.Equal(record.Time))
+				assert.Equal(t, tt.record.Level, record.Level)
+				assert.Equal(t, tt.record.Service, record.Service)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	warn := zapcore.WarnLevel
+	lines := []string{
+		`{"level":"info","ts":"2024-01-02T10:00:00.000Z","msg":"a","service":"api"}`,
+		`{"level":"error","ts":"2024-01-02T11:00:00.000Z","msg":"b","service":"api"}`,
+		`level=warn ts=2024-01-02T12:00:00Z msg=c service=worker`,
+		"plain text",
+	}
+
+	tests := []struct {
+		name     string
+		filter   *Filter
+		expected []int
+	}{
+		{name: "empty", filter: &Filter{}, expected: []int{0, 1, 2, 3}},
+		{name: "level", filter: &Filter{Level: &warn}, expected: []int{1, 2}},
+		{name: "service", filter: &Filter{Service: "api"}, expected: []int{0, 1}},
+		{name: "since", filter: &Filter{Since: time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)}, expected: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := make([]int, 0)
+			for i, line := range lines {
+				if tt.filter.Match([]byte(line)) {
+					matched = append(matched, i)
+				}
+			}
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestFollowLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.Nil(t, os.WriteFile(path, []byte("before\n"), 0644))
+
+	offsets, err := ReadLogs(context.Background(), []string{path}, nil, io.Discard)
+	assert.Nil(t, err)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+
+	// a line written after the file was read and before it is followed
+	_, err = f.WriteString("between\n")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+
+	go func() {
+		done <- FollowLog(ctx, path, offsets[path], nil, out)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return out.String() == "between\n"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = f.WriteString("after ")
+	assert.Nil(t, err)
+
+	time.Sleep(2 * followInterval)
+	assert.Equal(t, "between\n", out.String(), "a partial line is not written")
+
+	_, err = f.WriteString("appended\n")
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		return out.String() == "between\nafter appended\n"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// rotation replaces the file with a new one, the last line of the previous one is still written
+	_, err = f.WriteString("last\n")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	assert.Nil(t, os.Rename(path, path+".1"))
+	assert.Nil(t, os.WriteFile(path, []byte("rotated\n"), 0644))
+
+	assert.Eventually(t, func() bool {
+		return out.String() == "between\nafter appended\nlast\nrotated\n"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Nil(t, <-done)
+}
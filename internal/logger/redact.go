@@ -0,0 +1,365 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the redacted values
+const RedactedValue = "[REDACTED]"
+
+var (
+	ErrorInvalidRedactPattern = fmt.Errorf("logger: invalid redaction pattern")
+
+	// DefaultRedactKeys are the field names whose values are always redacted, the match ignores case and
+	// the prefixes, like db_password or DBPassword
+	DefaultRedactKeys = []string{
+		"password", "passwd", "secret", "token", "access_token", "refresh_token",
+		"api_key", "apikey", "authorization", "private_key", "client_secret",
+	}
+
+	// DefaultRedactPatterns match bearer tokens, values encrypted by the crypto package and card numbers
+	// written in groups, like 4111 1111 1111 1111 or 3782-822463-10005
+	DefaultRedactPatterns = []string{
+		`(?i)bearer\s+[a-z0-9\-._~+/]+=*`,
+		`enc:[A-Za-z0-9+/=_\-]+`,
+		`\b\d{4}(?:[ -]\d{4}){3}(?:[ -]\d{1,3})?\b`,
+		`\b\d{4}[ -]\d{6}[ -]\d{5}\b`,
+	}
+
+	// RedactCardDigits matches the card numbers written without separators, it is not a default pattern as
+	// about one in ten ids and epoch timestamps of the same length pass the luhn check
+	RedactCardDigits = `\b\d{13,19}\b`
+
+	cardNumber = regexp.MustCompile(`^(?:\d[ -]?){12,18}\d$`)
+)
+
+type (
+	// redactor masks the values of sensitive keys and the substrings matching the patterns
+	redactor struct {
+		keys     map[string]struct{}
+		keyValue *regexp.Regexp
+		patterns []*regexp.Regexp
+	}
+
+	// redactCore redacts the message and the fields before they reach the wrapped sink core
+	redactCore struct {
+		zapcore.Core
+		r *redactor
+	}
+
+	// redactObject marshals an object through a redacting encoder
+	redactObject struct {
+		obj zapcore.ObjectMarshaler
+		r   *redactor
+	}
+
+	// redactEncoder redacts the values added by nested objects
+	redactEncoder struct {
+		zapcore.ObjectEncoder
+		r *redactor
+	}
+
+	// redactArray marshals an array through a redacting encoder
+	redactArray struct {
+		arr zapcore.ArrayMarshaler
+		r   *redactor
+	}
+
+	// redactArrayEncoder redacts the elements added by arrays
+	redactArrayEncoder struct {
+		zapcore.ArrayEncoder
+		r *redactor
+	}
+)
+
+// newRedactor compiles the keys and patterns, it returns nil when there is nothing to redact
+func newRedactor(keys, patterns []string) (*redactor, error) {
+	if len(keys) == 0 && len(patterns) == 0 {
+		return nil, nil
+	}
+
+	r := &redactor{
+		keys:     make(map[string]struct{}, len(keys)),
+		patterns: make([]*regexp.Regexp, 0, len(patterns)),
+	}
+
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+
+	if len(quoted) > 0 {
+		// key=value and key: value pairs written in messages, the key may have prefixes like db_password
+		r.keyValue = regexp.MustCompile(`(?i)\b((?:[a-z0-9]+[_\-.])*(?:` + strings.Join(quoted, "|") + `))(\s*[=:]\s*)("[^"]*"|[^\s,;]+)`)
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrorInvalidRedactPattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// sensitive reports whether the values of key are redacted, the key or its part after a _, - or .
+// separator or starting with an upper case letter, like the Password of DBPassword, is one of the keys
+func (r *redactor) sensitive(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if i > 0 && !strings.ContainsRune("_-.", rune(key[i-1])) && (key[i] < 'A' || key[i] > 'Z') {
+			continue
+		}
+
+		if _, ok := r.keys[strings.ToLower(key[i:])]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString masks the substrings of s matching the patterns and the values of sensitive keys
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			// digit sequences are only card numbers when they pass the luhn check
+			if cardNumber.MatchString(match) && !luhn(match) {
+				return match
+			}
+			return RedactedValue
+		})
+	}
+
+	// after the patterns so a value matched by a pattern, like a bearer token, is masked whole
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, "${1}${2}"+RedactedValue)
+	}
+	return s
+}
+
+// redactFields returns the fields with the sensitive values masked, fields is not modified
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+
+	for i, field := range fields {
+		redacted[i] = r.redactField(field)
+	}
+	return redacted
+}
+
+func (r *redactor) redactField(field zapcore.Field) zapcore.Field {
+	if field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+		return field
+	}
+
+	if r.sensitive(field.Key) {
+		return zap.String(field.Key, RedactedValue)
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = r.redactString(field.String)
+	case zapcore.ByteStringType:
+		return zap.ByteString(field.Key, []byte(r.redactString(string(field.Interface.([]byte)))))
+	case zapcore.ObjectMarshalerType:
+		field.Interface = redactObject{obj: field.Interface.(zapcore.ObjectMarshaler), r: r}
+	case zapcore.ArrayMarshalerType:
+		field.Interface = redactArray{arr: field.Interface.(zapcore.ArrayMarshaler), r: r}
+	case zapcore.StringerType, zapcore.ErrorType:
+		return zap.String(field.Key, r.redactString(fmt.Sprint(field.Interface)))
+	case zapcore.ReflectType:
+		return zap.Any(field.Key, r.redactValue(field.Interface))
+	}
+	return field
+}
+
+// redactValue redacts the strings and the sensitive keys of maps, slices and structs logged with zap.Any
+func (r *redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, val := range v {
+			if r.sensitive(key) {
+				redacted[key] = RedactedValue
+				continue
+			}
+			redacted[key] = r.redactString(val)
+		}
+		return redacted
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, val := range v {
+			if r.sensitive(key) {
+				redacted[key] = RedactedValue
+				continue
+			}
+			redacted[key] = r.redactValue(val)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, val := range v {
+			redacted[i] = r.redactString(val)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, val := range v {
+			redacted[i] = r.redactValue(val)
+		}
+		return redacted
+	default:
+		return r.redactReflected(value)
+	}
+}
+
+// redactReflected redacts the other structs, maps and slices as they are encoded, through their json form
+func (r *redactor) redactReflected(value any) any {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+	default:
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		return value
+	}
+	return r.redactValue(decoded)
+}
+
+// luhn reports whether the digits of s pass the luhn checksum of card numbers
+func luhn(s string) bool {
+	sum, double := 0, false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+
+		digit := int(s[i] - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{
+		Core: c.Core.With(c.r.redactFields(fields)),
+		r:    c.r,
+	}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.redactString(ent.Message)
+	return c.Core.Write(ent, c.r.redactFields(fields))
+}
+
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.obj.MarshalLogObject(&redactEncoder{ObjectEncoder: enc, r: o.r})
+}
+
+func (e *redactEncoder) AddString(key, value string) {
+	if e.r.sensitive(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return
+	}
+	e.ObjectEncoder.AddString(key, e.r.redactString(value))
+}
+
+func (e *redactEncoder) AddByteString(key string, value []byte) {
+	if e.r.sensitive(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return
+	}
+	e.ObjectEncoder.AddByteString(key, []byte(e.r.redactString(string(value))))
+}
+
+func (e *redactEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	if e.r.sensitive(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactObject{obj: obj, r: e.r})
+}
+
+func (e *redactEncoder) AddReflected(key string, value any) error {
+	if e.r.sensitive(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return nil
+	}
+	return e.ObjectEncoder.AddReflected(key, e.r.redactValue(value))
+}
+
+func (e *redactEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	if e.r.sensitive(key) {
+		e.ObjectEncoder.AddString(key, RedactedValue)
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, redactArray{arr: arr, r: e.r})
+}
+
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.arr.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, r: a.r})
+}
+
+func (e *redactArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(e.r.redactString(value))
+}
+
+func (e *redactArrayEncoder) AppendByteString(value []byte) {
+	e.ArrayEncoder.AppendByteString([]byte(e.r.redactString(string(value))))
+}
+
+func (e *redactArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObject{obj: obj, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArray{arr: arr, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendReflected(value any) error {
+	return e.ArrayEncoder.AppendReflected(e.r.redactValue(value))
+}
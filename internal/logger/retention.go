@@ -0,0 +1,388 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	megabyte = 1024 * 1024
+	// backupTimeFormat is the time format lumberjack adds to the rotated file names
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	// defaultLumberjackSize is the file size lumberjack uses when none is set, in megabytes
+	defaultLumberjackSize = 100
+	compressSuffix        = ".gz"
+)
+
+type (
+	// RotateHook is called with the path of each rotated log file, compressed when compression is enabled,
+	// a hook that moves the file must be the last one
+	RotateHook func(path string) error
+
+	// rotatingWriter rotates the lumberjack file itself so it can compress the rotated file, call the
+	// rotation hooks and keep the log files under the total size budget
+	rotatingWriter struct {
+		*lumberjack.Logger
+		mutex      sync.Mutex
+		size       int64
+		maxSize    int64
+		compress   bool
+		maxTotal   int64
+		archiveDir string
+		hooks      []RotateHook
+		rotated    chan struct{}
+		processed  map[string]struct{}
+		closed     bool
+	}
+
+	// rotatingCore is the core of a file sink with a rotating writer, closed with the logger
+	rotatingCore struct {
+		zapcore.Core
+		w *rotatingWriter
+	}
+
+	// backupFile is a rotated log file, stamp is the rotation time in its name
+	backupFile struct {
+		path  string
+		size  int64
+		stamp string
+	}
+)
+
+// SetRetention limits the total size in megabytes of the log files, the ones of every logger writing
+// to the log directory and the archived ones, the oldest rotated files are removed once it is exceeded,
+// zero disables the limit
+func (c *Config) SetRetention(maxTotalSize int) {
+	c.MaxTotalSize = maxTotalSize
+}
+
+// AddRotateHook adds a hook called after each rotation of the log file
+func (c *Config) AddRotateHook(hook RotateHook) {
+	c.RotateHooks = append(c.RotateHooks, hook)
+}
+
+// SetArchiveDir moves the rotated files to dir, they count in the retention budget
+func (c *Config) SetArchiveDir(dir string) {
+	c.ArchiveDir = dir
+	c.AddRotateHook(ArchiveDir(dir))
+}
+
+// ArchiveDir returns a rotation hook moving the rotated files to dir
+func ArchiveDir(dir string) RotateHook {
+	return func(path string) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.Base(path))
+		if err := os.Rename(path, target); err == nil {
+			return nil
+		}
+
+		// the archive may be on another device
+		if err := copyFile(path, target); err != nil {
+			return err
+		}
+		return os.Remove(path)
+	}
+}
+
+// newRotatingWriter wraps the lumberjack logger of cfg, the compression is done after the hooks are called
+func newRotatingWriter(lj *lumberjack.Logger, cfg *Config) *rotatingWriter {
+	maxSize := int64(lj.MaxSize)
+	if maxSize == 0 {
+		maxSize = defaultLumberjackSize
+	}
+
+	w := &rotatingWriter{
+		Logger:     lj,
+		maxSize:    maxSize * megabyte,
+		compress:   lj.Compress,
+		maxTotal:   int64(cfg.MaxTotalSize) * megabyte,
+		archiveDir: cfg.ArchiveDir,
+		hooks:      cfg.RotateHooks,
+		rotated:    make(chan struct{}, 1),
+		processed:  make(map[string]struct{}),
+	}
+	lj.Compress = false
+
+	if info, err := os.Stat(lj.Filename); err == nil {
+		w.size = info.Size()
+	}
+
+	// the files rotated by a previous run are not handed to the hooks again
+	for _, backup := range w.backups() {
+		w.processed[backup.path] = struct{}{}
+	}
+
+	go w.run()
+	return w
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.Logger.Rotate(); err != nil {
+			return 0, err
+		}
+		w.size = 0
+		w.notify()
+	}
+
+	n, err := w.Logger.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the file and stops processing the rotated files, a later write opens the file again
+func (w *rotatingWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.closed {
+		w.closed = true
+		close(w.rotated)
+	}
+	return w.Logger.Close()
+}
+
+// notify starts a pass over the rotated files, the mutex must be held
+func (w *rotatingWriter) notify() {
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.rotated <- struct{}{}:
+	default:
+		// a pass is already pending, it handles this file too
+	}
+}
+
+// run processes the rotated files in the background so writes are not blocked
+func (w *rotatingWriter) run() {
+	for range w.rotated {
+		w.process()
+	}
+}
+
+// process compresses the new rotated files, calls the hooks and enforces the size budget
+func (w *rotatingWriter) process() {
+	for _, backup := range w.backups() {
+		if _, ok := w.processed[backup.path]; ok {
+			continue
+		}
+
+		path := backup.path
+		if w.compress && !strings.HasSuffix(path, compressSuffix) {
+			compressed, err := compressFile(path)
+			if err != nil {
+				Warnw("failed to compress rotated log file", "file", path, "error", err)
+			} else {
+				path = compressed
+			}
+		}
+
+		w.processed[path] = struct{}{}
+
+		for _, hook := range w.hooks {
+			if err := hook(path); err != nil {
+				Warnw("log rotation hook failed", "file", path, "error", err)
+			}
+		}
+	}
+
+	w.enforceBudget()
+}
+
+// enforceBudget removes the oldest rotated files until the log files fit in the budget, the files of
+// the other loggers writing to the log directory, like the per service ones, and the archived files count
+// too, the current files are never removed
+func (w *rotatingWriter) enforceBudget() {
+	if w.maxTotal <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(w.Filename)
+	dirs := []string{filepath.Dir(w.Filename)}
+	if w.archiveDir != "" && filepath.Clean(w.archiveDir) != dirs[0] {
+		dirs = append(dirs, w.archiveDir)
+	}
+
+	total := int64(0)
+	backups := make([]backupFile, 0)
+	for _, dir := range dirs {
+		current, rotated := logFiles(dir, ext)
+		total += current
+		backups = append(backups, rotated...)
+	}
+
+	// the oldest files of every logger first
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].stamp != backups[j].stamp {
+			return backups[i].stamp < backups[j].stamp
+		}
+		return backups[i].path < backups[j].path
+	})
+
+	for _, backup := range backups {
+		total += backup.size
+	}
+
+	for _, backup := range backups {
+		if total <= w.maxTotal {
+			return
+		}
+
+		if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+			Warnw("failed to remove rotated log file", "file", backup.path, "error", err)
+			continue
+		}
+
+		delete(w.processed, backup.path)
+		total -= backup.size
+	}
+}
+
+// backups returns the rotated files of the logger, the oldest first
+func (w *rotatingWriter) backups() []backupFile {
+	dir := filepath.Dir(w.Filename)
+	ext := filepath.Ext(w.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(w.Filename), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	backups := make([]backupFile, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{
+			path: filepath.Join(dir, name),
+			size: info.Size(),
+		})
+	}
+
+	// the time stamps have a fixed width so the names sort by time
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].path < backups[j].path
+	})
+	return backups
+}
+
+// logFiles returns the size of the current log files of dir and its rotated files, only the files with
+// the log extension ext, compressed or not, are listed
+func logFiles(dir, ext string) (int64, []backupFile) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, nil
+	}
+
+	current := int64(0)
+	backups := make([]backupFile, 0)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if entry.IsDir() || filepath.Ext(name) != ext {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if _, ok := backupOwner(name); !ok {
+			current += info.Size()
+			continue
+		}
+
+		stem := strings.TrimSuffix(name, ext)
+		backups = append(backups, backupFile{
+			path:  filepath.Join(dir, entry.Name()),
+			size:  info.Size(),
+			stamp: stem[len(stem)-len(backupTimeFormat):],
+		})
+	}
+	return current, backups
+}
+
+// compressFile gzips path next to it and removes it, it returns the path of the compressed file
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	target := path + compressSuffix
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return "", err
+	}
+
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		return "", err
+	}
+
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+
+	_ = src.Close()
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("logger: failed to remove compressed log file: %w", err)
+	}
+	return target, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
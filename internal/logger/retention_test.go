@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeLines writes count lines of 60 bytes, waiting between them so the rotated files have distinct names
+func writeLines(t *testing.T, w io.Writer, count int) {
+	for i := 0; i < count; i++ {
+		_, err := w.Write([]byte(strings.Repeat("x", 59) + "\n"))
+		assert.Nil(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRotateHooks(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "archive")
+
+	var mutex sync.Mutex
+	rotated := make([]string, 0)
+
+	cfg := NewDefaultConfig()
+	cfg.AddRotateHook(func(path string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		rotated = append(rotated, path)
+		return nil
+	})
+	cfg.AddRotateHook(ArchiveDir(archive))
+
+	w := newRotatingWriter(&lumberjack.Logger{Filename: filepath.Join(dir, "app.log"), MaxSize: 1, Compress: true}, cfg)
+	w.maxSize = 100
+	t.Cleanup(func() {
+		_ = w.Close()
+	})
+
+	writeLines(t, w, 4)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(rotated) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	entries, err := os.ReadDir(archive)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 3)
+	assert.Empty(t, w.backups())
+
+	for _, path := range rotated {
+		assert.True(t, strings.HasSuffix(path, ".log.gz"), path)
+	}
+
+	f, err := os.Open(filepath.Join(archive, entries[0].Name()))
+	assert.Nil(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(gz)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("x", 59)+"\n", string(data))
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := NewDefaultConfig()
+	cfg.SetRetention(1)
+
+	w := newRotatingWriter(&lumberjack.Logger{Filename: filepath.Join(dir, "app.log"), MaxSize: 1}, cfg)
+	w.maxSize = 100
+	w.maxTotal = 150
+	t.Cleanup(func() {
+		_ = w.Close()
+	})
+
+	writeLines(t, w, 5)
+
+	// the current file and the newest rotated file fit in the budget
+	assert.Eventually(t, func() bool {
+		return len(w.backups()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := os.Stat(filepath.Join(dir, "app.log"))
+	assert.Nil(t, err)
+}
+
+func TestRetentionDirectory(t *testing.T) {
+	dir := t.TempDir()
+	archive := t.TempDir()
+
+	files := map[string]int{
+		filepath.Join(dir, "svc-host-2021-01-01T10-00-00.000.log"):        100,
+		filepath.Join(dir, "svc-host.log"):                                50,
+		filepath.Join(dir, "notes.txt"):                                   1000,
+		filepath.Join(archive, "app-2020-01-01T10-00-00.000.log.gz"):      100,
+		filepath.Join(archive, "svc-host-2022-01-01T10-00-00.000.log.gz"): 10,
+	}
+	for path, size := range files {
+		assert.Nil(t, os.WriteFile(path, make([]byte, size), 0644))
+	}
+
+	cfg := NewDefaultConfig()
+	cfg.SetRetention(1)
+	cfg.ArchiveDir = archive
+
+	w := newRotatingWriter(&lumberjack.Logger{Filename: filepath.Join(dir, "app.log"), MaxSize: 1}, cfg)
+	w.maxSize = 100
+	w.maxTotal = 250
+	t.Cleanup(func() {
+		_ = w.Close()
+	})
+
+	writeLines(t, w, 2)
+
+	// the oldest rotated files of every logger go first until the directories fit in the budget
+	removed := []string{
+		filepath.Join(archive, "app-2020-01-01T10-00-00.000.log.gz"),
+		filepath.Join(dir, "svc-host-2021-01-01T10-00-00.000.log"),
+	}
+	assert.Eventually(t, func() bool {
+		for _, path := range removed {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	for _, path := range []string{
+		filepath.Join(dir, "svc-host.log"),
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(archive, "svc-host-2022-01-01T10-00-00.000.log.gz"),
+	} {
+		_, err := os.Stat(path)
+		assert.Nil(t, err, path)
+	}
+	assert.Len(t, w.backups(), 1)
+}
+
+func TestRotatingWriterClose(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.SetRetention(1)
+	assert.Nil(t, cfg.SetPath(t.TempDir(), "app", "local"))
+
+	previous, previousCores := zapLogger, zapCores
+	t.Cleanup(func() {
+		setLogger(previous, previousCores, nil)
+	})
+
+	assert.Nil(t, NewLogger(cfg))
+	var first *rotatingWriter
+	for _, core := range zapCores {
+		if rc, ok := core.(*redactCore); ok {
+			core = rc.Core
+		}
+		if rc, ok := core.(*rotatingCore); ok {
+			first = rc.w
+		}
+	}
+	assert.NotNil(t, first)
+
+	// replacing the logger closes the writer of the previous one
+	assert.Nil(t, NewLogger(cfg))
+	first.mutex.Lock()
+	assert.True(t, first.closed)
+	first.mutex.Unlock()
+
+	// a write after the close does not signal the stopped goroutine
+	first.maxSize = 100
+	writeLines(t, first, 3)
+	assert.Nil(t, first.Close())
+}
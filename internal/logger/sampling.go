@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// maxLimiterKeys is the number of messages tracked by Every before the expired ones are dropped
+const maxLimiterKeys = 1024
+
+var limiter = &rateLimiter{
+	last: make(map[string]time.Time),
+}
+
+type (
+	// Sampling keeps the First entries with the same level and message every Tick, then every Thereafter-th one
+	Sampling struct {
+		Tick       time.Duration
+		First      int
+		Thereafter int
+	}
+
+	// rateLimiter remembers when each message was last written
+	rateLimiter struct {
+		mutex sync.Mutex
+		last  map[string]time.Time
+	}
+
+	// dedupCore collapses identical consecutive entries written within window into a single entry
+	// followed by a "repeated N times" summary, written when another entry arrives, when the window of
+	// the repeated entry ends or on Sync
+	dedupCore struct {
+		zapcore.Core
+		window time.Duration
+		state  *dedupState
+	}
+
+	dedupState struct {
+		mutex  sync.Mutex
+		key    string
+		entry  zapcore.Entry
+		fields []zapcore.Field
+		count  int
+		timer  *time.Timer
+	}
+)
+
+// Every returns a logger writing each message at most once per interval, see Logger.Every
+func Every(interval time.Duration) *Logger {
+	return root.Every(interval)
+}
+
+// Every returns a child logger writing each message at most once per interval, the message is the key
+// so it should not contain values that change on every call, pass them as fields
+func (l *Logger) Every(interval time.Duration) *Logger {
+	return &Logger{
+		service: l.service,
+		fields:  l.fields,
+		zl:      l.zl,
+		every:   interval,
+	}
+}
+
+// allow reports whether key was not written in the last interval and records it as written now
+func (r *rateLimiter) allow(key string, interval time.Duration) bool {
+	now := time.Now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if last, ok := r.last[key]; ok && now.Sub(last) < interval {
+		return false
+	}
+
+	if len(r.last) >= maxLimiterKeys {
+		r.prune(now, interval)
+	}
+
+	r.last[key] = now
+	return true
+}
+
+// prune drops the keys not written in the last interval, the mutex must be held
+func (r *rateLimiter) prune(now time.Time, interval time.Duration) {
+	for key, last := range r.last {
+		if now.Sub(last) >= interval {
+			delete(r.last, key)
+		}
+	}
+}
+
+// SetSampling enables the zap sampler, a zero tick disables it
+func (c *Config) SetSampling(tick time.Duration, first, thereafter int) {
+	if tick <= 0 {
+		c.Sampling = nil
+		return
+	}
+
+	c.Sampling = &Sampling{
+		Tick:       tick,
+		First:      first,
+		Thereafter: thereafter,
+	}
+}
+
+// SetDedup collapses identical consecutive entries written within window, a zero window disables it
+func (c *Config) SetDedup(window time.Duration) {
+	c.Dedup = window
+}
+
+// wrapCore adds the dedup and sampling settings of the config to core
+func wrapCore(cfg *Config, core zapcore.Core) zapcore.Core {
+	if cfg.Dedup > 0 {
+		core = newDedupCore(core, cfg.Dedup)
+	}
+
+	if cfg.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, cfg.Sampling.Tick, cfg.Sampling.First, cfg.Sampling.Thereafter)
+	}
+	return core
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration) *dedupCore {
+	return &dedupCore{
+		Core:   core,
+		window: window,
+		state:  &dedupState{},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return newDedupCore(c.Core.With(fields), c.window)
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := dedupKey(ent, fields)
+
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+
+	if key == c.state.key && ent.Time.Sub(c.state.entry.Time) < c.window {
+		c.state.count++
+		if c.state.timer == nil {
+			// the summary is written when the window ends even if no other entry arrives
+			c.state.timer = time.AfterFunc(time.Until(c.state.entry.Time.Add(c.window)), c.expire)
+		}
+		return nil
+	}
+
+	c.flush()
+
+	c.state.key = key
+	c.state.entry = ent
+	c.state.fields = fields
+	return c.write(ent, fields)
+}
+
+// Sync writes the pending summary before syncing the wrapped core
+func (c *dedupCore) Sync() error {
+	c.state.mutex.Lock()
+	c.flush()
+	c.state.mutex.Unlock()
+
+	return c.Core.Sync()
+}
+
+// expire writes the pending summary when the window of the repeated entry ends
+func (c *dedupCore) expire() {
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+
+	c.flush()
+}
+
+// flush writes the summary of the repeated entry, the mutex must be held
+func (c *dedupCore) flush() {
+	if c.state.timer != nil {
+		c.state.timer.Stop()
+		c.state.timer = nil
+	}
+
+	if c.state.count == 0 {
+		return
+	}
+
+	summary := c.state.entry
+	summary.Time = time.Now()
+	summary.Message = fmt.Sprintf("%s (repeated %d times)", summary.Message, c.state.count)
+	if c.state.count == 1 {
+		summary.Message = fmt.Sprintf("%s (repeated once)", c.state.entry.Message)
+	}
+
+	c.state.count = 0
+	_ = c.write(summary, c.state.fields)
+}
+
+// write checks the entry against the wrapped core so each sink applies its own level
+func (c *dedupCore) write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// dedupKey identifies an entry by its level, message and fields
+func dedupKey(ent zapcore.Entry, fields []zapcore.Field) string {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return fmt.Sprintf("%s|%s|%v", ent.Level, ent.Message, enc.Fields)
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+// observeWrapped replaces the global logger with one recording the entries through the core wrappers of cfg
+func observeWrapped(t *testing.T, cfg *Config) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	previous := zapLogger
+	zapLogger = zap.New(wrapCore(cfg, core))
+	t.Cleanup(func() {
+		zapLogger = previous
+	})
+	return logs
+}
+
+func TestEvery(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+
+	// the limiter is global, forget the messages written by a previous run of the test
+	limiter.mutex.Lock()
+	limiter.last = make(map[string]time.Time)
+	limiter.mutex.Unlock()
+
+	for i := 0; i < 5; i++ {
+		Every(time.Hour).Info("tick", "i", i)
+		Service("api").Every(time.Hour).Info("tick")
+	}
+	Every(time.Hour).Info("other")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, map[string]any{"i": int64(0)}, entries[0].ContextMap())
+	assert.Equal(t, "api", entries[1].ContextMap()["service"])
+	assert.Equal(t, "other", entries[2].Message)
+
+	assert.True(t, limiter.allow("expired", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	assert.True(t, limiter.allow("expired", time.Nanosecond))
+}
+
+func TestSampling(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.SetSampling(time.Hour, 2, 3)
+	logs := observeWrapped(t, cfg)
+
+	for i := 0; i < 10; i++ {
+		Info("tick")
+	}
+
+	// the first 2, then every 3rd: 5th and 8th
+	assert.Equal(t, 4, logs.Len())
+}
+
+func TestDedup(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.SetDedup(time.Hour)
+	logs := observeWrapped(t, cfg)
+
+	for i := 0; i < 4; i++ {
+		Infow("connection refused", "host", "db")
+	}
+	Infow("connection refused", "host", "cache")
+	Info("connected")
+	Info("connected")
+	assert.Nil(t, zapLogger.Sync())
+
+	messages := make([]string, 0)
+	for _, entry := range logs.AllUntimed() {
+		messages = append(messages, entry.Message)
+	}
+
+	assert.Equal(t, []string{
+		"connection refused",
+		"connection refused (repeated 3 times)",
+		"connection refused",
+		"connected",
+		"connected (repeated once)",
+	}, messages)
+	assert.Equal(t, "db", logs.AllUntimed()[1].ContextMap()["host"])
+}
+
+func TestDedupWindowEnd(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.SetDedup(50 * time.Millisecond)
+	logs := observeWrapped(t, cfg)
+
+	Info("retrying")
+	Info("retrying")
+
+	// the summary is written without another entry or a sync
+	assert.Eventually(t, func() bool {
+		return logs.FilterMessage("retrying (repeated once)").Len() == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	SinkStdout   = "stdout"
+	SinkStderr   = "stderr"
+	SinkFile     = "file"
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+
+	defaultSyslogAddress   = "/dev/log"
+	defaultJournaldAddress = "/run/systemd/journal/socket"
+	syslogFacilityUser     = 1
+)
+
+var (
+	ErrorInvalidSink = fmt.Errorf("logger: invalid sink, use %s, %s, %s, %s or %s", SinkStdout, SinkStderr, SinkFile, SinkSyslog, SinkJournald)
+	sinkPool         = buffer.NewPool()
+)
+
+type (
+	// Sink is a destination of the log entries, entries below Level are not written to it.
+	// Encoder defaults to the config encoder, Address is the socket path of the syslog and journald sinks
+	// and Tag the syslog identifier, the program name by default.
+	Sink struct {
+		Type    string `json:"type"`
+		Level   string `json:"level,omitempty"`
+		Encoder string `json:"encoder,omitempty"`
+		Address string `json:"address,omitempty"`
+		Tag     string `json:"tag,omitempty"`
+	}
+
+	// socketCore writes each entry as a datagram to a local socket, format frames the encoded entry
+	socketCore struct {
+		zapcore.LevelEnabler
+		enc    zapcore.Encoder
+		conn   net.Conn
+		format func(buf *buffer.Buffer, ent zapcore.Entry, line []byte)
+	}
+)
+
+// validate checks the sink type, level and encoder
+func (s *Sink) validate() error {
+	switch s.Type {
+	case SinkStdout, SinkStderr, SinkFile, SinkSyslog, SinkJournald:
+	default:
+		return fmt.Errorf("%w: %s", ErrorInvalidSink, s.Type)
+	}
+
+	if s.Level != "" {
+		if _, err := ParseLevel(s.Level); err != nil {
+			return err
+		}
+	}
+
+	switch s.Encoder {
+	case "", EncoderAuto, EncoderConsole, EncoderJSON, EncoderLogfmt, EncoderPretty:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrorInvalidEncoder, s.Encoder)
+	}
+}
+
+// sinks returns the sinks of the config, stdout or the log file when none is configured
+func (c *Config) sinks() []Sink {
+	if len(c.Sinks) > 0 {
+		return c.Sinks
+	}
+
+	if c.Stdout {
+		return []Sink{{Type: SinkStdout}}
+	}
+	return []Sink{{Type: SinkFile}}
+}
+
+// newSinkCore creates the core writing to a sink, it also returns a description of the destination
+func newSinkCore(cfg *Config, sink Sink) (zapcore.Core, string, error) {
+	encoderName := sink.Encoder
+	if encoderName == "" {
+		encoderName = cfg.Encoder
+	}
+
+	encoder, err := buildEncoder(encoderName, cfg.TimeFormat, sinkRenderer(sink))
+	if err != nil {
+		return nil, "", err
+	}
+
+	enabler, err := sinkEnabler(sink.Level)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch sink.Type {
+	case SinkStdout:
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), enabler), SinkStdout, nil
+	case SinkStderr:
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), enabler), SinkStderr, nil
+	case SinkFile:
+		writer, err := newFileWriter(cfg)
+		if err != nil {
+			return nil, "", err
+		}
+
+		destination := fmt.Sprintf("%s: %s", SinkFile, writer.Filename)
+		if cfg.MaxTotalSize > 0 || len(cfg.RotateHooks) > 0 {
+			rw := newRotatingWriter(writer, cfg)
+			return &rotatingCore{Core: zapcore.NewCore(encoder, zapcore.AddSync(rw), enabler), w: rw}, destination, nil
+		}
+		return zapcore.NewCore(encoder, zapcore.AddSync(writer), enabler), destination, nil
+	case SinkSyslog:
+		return newSocketCore(sink, defaultSyslogAddress, encoder, enabler, syslogFormat(sinkTag(sink)))
+	case SinkJournald:
+		return newSocketCore(sink, defaultJournaldAddress, encoder, enabler, journaldFormat(sinkTag(sink)))
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrorInvalidSink, sink.Type)
+	}
+}
+
+// sinkRenderer returns the renderer of the terminal sinks, used to choose the auto encoder
+func sinkRenderer(sink Sink) *lipgloss.Renderer {
+	switch sink.Type {
+	case SinkStdout:
+		return lipgloss.DefaultRenderer()
+	case SinkStderr:
+		return lipgloss.NewRenderer(os.Stderr)
+	default:
+		return nil
+	}
+}
+
+// sinkEnabler enables the levels of the sink that are also enabled by the configured levels,
+// the configured levels still decide alone when the sink has no level
+func sinkEnabler(level string) (zapcore.LevelEnabler, error) {
+	if level == "" {
+		return levels.minimum, nil
+	}
+
+	threshold, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return threshold.Enabled(l) && levels.minimum.Enabled(l)
+	}), nil
+}
+
+// newFileWriter creates the rotating file writer of the log directory
+func newFileWriter(cfg *Config) (*lumberjack.Logger, error) {
+	if !cfg.validated {
+		return nil, ErrorLoggerConfigNotValidated
+	}
+
+	if _, err := os.Stat(cfg.LogDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
+			return nil, fmt.Errorf("logger: failed to create log directory: %w", err)
+		}
+	}
+
+	return &lumberjack.Logger{
+		Filename:   filepath.Join(cfg.LogDir, cfg.Filename),
+		MaxSize:    cfg.MaxFileSize,
+		MaxBackups: cfg.MaxBackups,
+		LocalTime:  cfg.LocalTime,
+		Compress:   cfg.Compress,
+		MaxAge:     cfg.MaxAge,
+	}, nil
+}
+
+func sinkTag(sink Sink) string {
+	if sink.Tag != "" {
+		return sink.Tag
+	}
+	return filepath.Base(os.Args[0])
+}
+
+func newSocketCore(sink Sink, defaultAddress string, enc zapcore.Encoder, enabler zapcore.LevelEnabler, format func(*buffer.Buffer, zapcore.Entry, []byte)) (zapcore.Core, string, error) {
+	address := sink.Address
+	if address == "" {
+		address = defaultAddress
+	}
+
+	conn, err := net.Dial("unixgram", address)
+	if err != nil {
+		return nil, "", fmt.Errorf("logger: %s sink: %w", sink.Type, err)
+	}
+
+	return &socketCore{
+		LevelEnabler: enabler,
+		enc:          enc,
+		conn:         conn,
+		format:       format,
+	}, fmt.Sprintf("%s: %s", sink.Type, address), nil
+}
+
+func (c *socketCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	return &socketCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          enc,
+		conn:         c.conn,
+		format:       c.format,
+	}
+}
+
+func (c *socketCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *socketCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	encoded, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer encoded.Free()
+
+	buf := sinkPool.Get()
+	defer buf.Free()
+
+	c.format(buf, ent, []byte(strings.TrimSuffix(encoded.String(), "\n")))
+	_, err = c.conn.Write(buf.Bytes())
+	return err
+}
+
+func (c *socketCore) Sync() error {
+	return nil
+}
+
+// severity returns the syslog severity of a level
+func severity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// syslogFormat frames the entries as the local syslog daemons expect them: <PRI>TIMESTAMP TAG[PID]: MSG
+func syslogFormat(tag string) func(*buffer.Buffer, zapcore.Entry, []byte) {
+	pid := os.Getpid()
+
+	return func(buf *buffer.Buffer, ent zapcore.Entry, line []byte) {
+		buf.AppendString(fmt.Sprintf("<%d>%s %s[%d]: ", syslogFacilityUser*8+severity(ent.Level), ent.Time.Format(time.Stamp), tag, pid))
+		buf.AppendString(string(line))
+	}
+}
+
+// journaldFormat frames the entries with the journald native protocol, the encoded entry is the message
+func journaldFormat(tag string) func(*buffer.Buffer, zapcore.Entry, []byte) {
+	return func(buf *buffer.Buffer, ent zapcore.Entry, line []byte) {
+		appendJournalField(buf, "PRIORITY", []byte(fmt.Sprint(severity(ent.Level))))
+		appendJournalField(buf, "SYSLOG_IDENTIFIER", []byte(tag))
+		appendJournalField(buf, "MESSAGE", line)
+	}
+}
+
+// appendJournalField appends KEY=value, values with a new line use the length prefixed binary form
+func appendJournalField(buf *buffer.Buffer, key string, value []byte) {
+	buf.AppendString(key)
+
+	if !strings.ContainsRune(string(value), '\n') {
+		buf.AppendByte('=')
+		buf.AppendString(string(value))
+		buf.AppendByte('\n')
+		return
+	}
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(value)))
+
+	buf.AppendByte('\n')
+	_, _ = buf.Write(size)
+	_, _ = buf.Write(value)
+	buf.AppendByte('\n')
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen opens a unix datagram socket standing for the syslog or journald socket
+func listen(t *testing.T, path string) *net.UnixConn {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets are not supported")
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// read returns the first datagram containing substr, skipping the ones before it
+func read(t *testing.T, conn *net.UnixConn, substr string) string {
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if !assert.Nil(t, err) {
+			return ""
+		}
+
+		if msg := string(buf[:n]); strings.Contains(msg, substr) {
+			return msg
+		}
+	}
+}
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	syslogConn := listen(t, filepath.Join(dir, "syslog.sock"))
+	journalConn := listen(t, filepath.Join(dir, "journal.sock"))
+
+	previous := zapLogger
+	t.Cleanup(func() {
+		zapLogger = previous
+	})
+
+	cfg := NewDefaultConfig()
+	assert.Nil(t, cfg.SetPath(dir, "", "sinks"))
+	assert.Nil(t, cfg.AddSink(Sink{Type: SinkFile, Encoder: EncoderJSON, Level: "warn"}))
+	assert.Nil(t, cfg.AddSink(Sink{Type: SinkSyslog, Address: filepath.Join(dir, "syslog.sock"), Tag: "app"}))
+	assert.Nil(t, cfg.AddSink(Sink{Type: SinkJournald, Encoder: EncoderLogfmt, Address: filepath.Join(dir, "journal.sock"), Tag: "app"}))
+	assert.ErrorIs(t, cfg.AddSink(Sink{Type: "kafka"}), ErrorInvalidSink)
+	assert.ErrorIs(t, cfg.AddSink(Sink{Type: SinkStdout, Level: "loud"}), ErrorInvalidLevel)
+	assert.Nil(t, NewLogger(cfg))
+
+	Infow("started", "port", 8080)
+	Warnw("slow request", "path", "/status")
+
+	msg := read(t, syslogConn, "started")
+	assert.True(t, strings.HasPrefix(msg, "<14>"), msg)
+	assert.Contains(t, msg, "app[")
+	assert.True(t, strings.HasPrefix(read(t, syslogConn, "slow request"), "<12>"))
+
+	assert.Contains(t, read(t, journalConn, "started"), "PRIORITY=6\nSYSLOG_IDENTIFIER=app\nMESSAGE=level=info ts=")
+
+	data, err := os.ReadFile(filepath.Join(dir, "default-sinks.log"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "started")
+	assert.Contains(t, string(data), `"msg":"slow request","path":"/status"`)
+}
@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"runtime"
+)
+
+type (
+	// SlogHandler is a log/slog handler writing to the global zap logger
+	SlogHandler struct {
+		// fields are the attributes added before any group is open
+		fields []zap.Field
+		groups []slogGroup
+	}
+
+	// slogGroup is a group open with WithGroup and the attributes added while it is the innermost one
+	slogGroup struct {
+		name  string
+		attrs []slog.Attr
+	}
+)
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a slog.Handler writing to the logger of this package
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{}
+}
+
+// Slog returns a slog.Logger writing to the logger of this package
+func Slog() *slog.Logger {
+	return slog.New(NewSlogHandler())
+}
+
+// Enabled reports whether the global logger writes entries of level
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return zapLogger != nil && levels.enabled("", slogToZapLevel(level))
+}
+
+// Handle writes the record with the handler attributes
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	level := slogToZapLevel(record.Level)
+	if !levels.enabled("", level) {
+		return nil
+	}
+
+	ce := zapLogger.Check(level, record.Message)
+	if ce == nil {
+		return nil
+	}
+
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+
+	// report where slog was called instead of this handler when the caller is enabled
+	if ce.Caller.Defined && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	ce.Write(append(append(make([]zap.Field, 0, len(h.fields)+1), h.fields...), h.groupFields(slogAttrs(attrs))...)...)
+	return nil
+}
+
+// WithAttrs returns a handler with the attributes added to every record, under the innermost open group
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	attrs = slogAttrs(attrs)
+	if len(attrs) == 0 {
+		return h
+	}
+
+	if len(h.groups) == 0 {
+		fields := make([]zap.Field, 0, len(h.fields)+len(attrs))
+		fields = append(fields, h.fields...)
+		for _, attr := range attrs {
+			fields = append(fields, attrToField(attr))
+		}
+		return &SlogHandler{fields: fields}
+	}
+
+	// the groups are copied, the handler they come from is still in use
+	groups := append(make([]slogGroup, 0, len(h.groups)), h.groups...)
+	last := &groups[len(groups)-1]
+	last.attrs = append(append(make([]slog.Attr, 0, len(last.attrs)+len(attrs)), last.attrs...), attrs...)
+
+	return &SlogHandler{
+		fields: h.fields,
+		groups: groups,
+	}
+}
+
+// WithGroup returns a handler that nests the next attributes under name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{
+		fields: h.fields,
+		groups: append(append(make([]slogGroup, 0, len(h.groups)+1), h.groups...), slogGroup{name: name}),
+	}
+}
+
+// groupFields nests the record attributes under the open groups with the attributes added to each one, a
+// single object is built per group and the groups left without attributes are dropped
+func (h *SlogHandler) groupFields(attrs []slog.Attr) []zap.Field {
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := h.groups[i]
+
+		nested := append(append(make([]slog.Attr, 0, len(group.attrs)+len(attrs)), group.attrs...), attrs...)
+		if len(nested) == 0 {
+			attrs = nil
+			continue
+		}
+		attrs = []slog.Attr{{Key: group.name, Value: slog.GroupValue(nested...)}}
+	}
+
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, attrToField(attr))
+	}
+	return fields
+}
+
+// slogAttrs resolves the attributes as the slog.Handler contract asks, the empty attributes and groups
+// are dropped and the attributes of a group without key are inlined
+func slogAttrs(attrs []slog.Attr) []slog.Attr {
+	resolved := make([]slog.Attr, 0, len(attrs))
+
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+
+		if attr.Value.Kind() == slog.KindGroup {
+			group := slogAttrs(attr.Value.Group())
+			if len(group) == 0 {
+				continue
+			}
+
+			if attr.Key == "" {
+				resolved = append(resolved, group...)
+				continue
+			}
+			attr.Value = slog.GroupValue(group...)
+		}
+		resolved = append(resolved, attr)
+	}
+	return resolved
+}
+
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"time"
+)
+
+const badKey = "!BADKEY"
+
+type (
+	// Logger is a structured logger carrying a set of fields added to every entry,
+	// it always writes to the current global logger so it can be created before the logger is configured
+	Logger struct {
+		service string
+		fields  []zap.Field
+		zl      *zap.Logger // writes to the global logger when nil
+		cores   []zapcore.Core
+		every   time.Duration
+	}
+
+	// attrGroup marshals a slog group as a zap object
+	attrGroup []slog.Attr
+)
+
+// With returns a structured logger with the given fields, see Infow for the accepted fields
+func With(fields ...any) *Logger {
+	return &Logger{
+		fields: toFields(fields),
+	}
+}
+
+// Service returns a structured logger of a service, its entries carry the service name and
+// follow the level override set with SetServiceLevel
+func Service(name string, fields ...any) *Logger {
+	return &Logger{
+		service: name,
+		fields:  append([]zap.Field{zap.String("service", name)}, toFields(fields)...),
+	}
+}
+
+// With returns a child logger with the fields of l plus the given fields
+func (l *Logger) With(fields ...any) *Logger {
+	return &Logger{
+		service: l.service,
+		zl:      l.zl,
+		every:   l.every,
+		fields:  append(append(make([]zap.Field, 0, len(l.fields)+len(fields)), l.fields...), toFields(fields)...),
+	}
+}
+
+// Info logs a message with the logger fields plus the given fields
+func (l *Logger) Info(msg string, fields ...any) {
+	l.log(zapcore.InfoLevel, msg, fields)
+}
+
+// Error logs a message with the logger fields plus the given fields
+func (l *Logger) Error(msg string, fields ...any) {
+	l.log(zapcore.ErrorLevel, msg, fields)
+}
+
+// Debug logs a message with the logger fields plus the given fields
+func (l *Logger) Debug(msg string, fields ...any) {
+	l.log(zapcore.DebugLevel, msg, fields)
+}
+
+// Warn logs a message with the logger fields plus the given fields
+func (l *Logger) Warn(msg string, fields ...any) {
+	l.log(zapcore.WarnLevel, msg, fields)
+}
+
+// Sync flushes the entries buffered by the logger of l
+func (l *Logger) Sync() error {
+	if l.zl == nil {
+		return Sync()
+	}
+	return l.zl.Sync()
+}
+
+// Close syncs and closes the sinks of a logger created with its own sinks, like NewServiceFileLogger,
+// the other loggers write to the global logger and are not closed
+func (l *Logger) Close() error {
+	if l.cores == nil {
+		return nil
+	}
+
+	err := l.zl.Sync()
+	closeCores(l.cores)
+	l.cores = nil
+	return err
+}
+
+func (l *Logger) log(level zapcore.Level, msg string, fields []any) {
+	if !levels.enabled(l.service, level) {
+		return
+	}
+
+	if l.every > 0 && !limiter.allow(l.service+"|"+msg, l.every) {
+		return
+	}
+
+	zl := l.zl
+	if zl == nil {
+		zl = zapLogger
+	}
+
+	if ce := zl.Check(level, msg); ce != nil {
+		ce.Write(append(append(make([]zap.Field, 0, len(l.fields)+len(fields)), l.fields...), toFields(fields)...)...)
+	}
+}
+
+// Infow logs a message with structured fields, fields are zap.Field, slog.Attr or key value pairs
+func Infow(msg string, fields ...any) {
+	root.log(zapcore.InfoLevel, msg, fields)
+}
+
+// Errorw logs a message with structured fields
+func Errorw(msg string, fields ...any) {
+	root.log(zapcore.ErrorLevel, msg, fields)
+}
+
+// Debugw logs a message with structured fields
+func Debugw(msg string, fields ...any) {
+	root.log(zapcore.DebugLevel, msg, fields)
+}
+
+// Warnw logs a message with structured fields
+func Warnw(msg string, fields ...any) {
+	root.log(zapcore.WarnLevel, msg, fields)
+}
+
+// toFields converts zap.Field, slog.Attr and key value pairs to zap fields,
+// a key without value or that is not a string is logged under !BADKEY
+func toFields(args []any) []zap.Field {
+	fields := make([]zap.Field, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		switch v := args[i].(type) {
+		case zap.Field:
+			fields = append(fields, v)
+		case slog.Attr:
+			fields = append(fields, attrToField(v))
+		case string:
+			if i+1 < len(args) {
+				fields = append(fields, zap.Any(v, args[i+1]))
+				i++
+				continue
+			}
+			fields = append(fields, zap.Any(badKey, v))
+		default:
+			fields = append(fields, zap.Any(badKey, v))
+		}
+	}
+	return fields
+}
+
+// attrToField converts a slog attribute to a zap field, groups become namespaced objects
+func attrToField(attr slog.Attr) zap.Field {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindBool:
+		return zap.Bool(attr.Key, value.Bool())
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, value.Duration())
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, value.Float64())
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, value.Int64())
+	case slog.KindString:
+		return zap.String(attr.Key, value.String())
+	case slog.KindTime:
+		return zap.Time(attr.Key, value.Time())
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, value.Uint64())
+	case slog.KindGroup:
+		return zap.Object(attr.Key, attrGroup(value.Group()))
+	default:
+		if err, ok := value.Any().(error); ok {
+			return zap.NamedError(attr.Key, err)
+		}
+		return zap.Any(attr.Key, value.Any())
+	}
+}
+
+func (g attrGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		attrToField(attr).AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+)
+
+// observe replaces the global logger with one recording the entries
+func observe(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	core, logs := observer.New(level)
+
+	previous := zapLogger
+	zapLogger = zap.New(core)
+	t.Cleanup(func() {
+		zapLogger = previous
+	})
+	return logs
+}
+
+func TestStructuredLogger(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+
+	l := With("service", "api").With(zap.Int("attempt", 2))
+	l.Info("request done", "status", 200, slog.String("path", "/status"))
+	l.Debug("not written")
+	Warnw("dangling", "key")
+	Info("printf %d", 1)
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "request done", entries[0].Message)
+	assert.Equal(t, map[string]any{
+		"service": "api",
+		"attempt": int64(2),
+		"status":  int64(200),
+		"path":    "/status",
+	}, entries[0].ContextMap())
+
+	assert.Equal(t, map[string]any{badKey: "key"}, entries[1].ContextMap())
+	assert.Equal(t, "printf 1", entries[2].Message)
+}
+
+func TestSlogHandler(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+
+	l := Slog().With("service", "api").WithGroup("http")
+	l.Info("request", "status", 200)
+	l.Debug("not written")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"service": "api",
+		"http":    map[string]any{"status": int64(200)},
+	}, entries[0].ContextMap())
+}
+
+func TestSlogHandlerGroups(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+
+	l := Slog().WithGroup("g").With("a", 1).With("b", 2)
+	l.Info("request", "c", 3)
+	l.WithGroup("h").Info("empty group")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, map[string]any{
+		"g": map[string]any{"a": int64(1), "b": int64(2), "c": int64(3)},
+	}, entries[0].ContextMap())
+	assert.Equal(t, map[string]any{
+		"g": map[string]any{"a": int64(1), "b": int64(2)},
+	}, entries[1].ContextMap())
+
+	// a single g object is written, the json has no duplicate key
+	assert.Len(t, entries[0].Context, 1)
+}
+
+func TestSlogHandlerContract(t *testing.T) {
+	logs := observe(t, zapcore.DebugLevel)
+
+	err := slogtest.TestHandler(NewSlogHandler(), func() []map[string]any {
+		results := make([]map[string]any, 0, logs.Len())
+		for _, entry := range logs.All() {
+			result := entry.ContextMap()
+			result[slog.TimeKey] = entry.Time
+			result[slog.LevelKey] = entry.Level
+			result[slog.MessageKey] = entry.Message
+			results = append(results, result)
+		}
+		return results
+	})
+
+	// zap writes the time of every entry, a record without time is logged with the time it is handled
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			if !strings.Contains(err.Error(), "zero Record.Time") {
+				errs = append(errs, err)
+			}
+		}
+	}
+	assert.Empty(t, errs)
+}
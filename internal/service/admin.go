@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/dyammarcano/application-manager/internal/cache"
+	"github.com/dyammarcano/application-manager/internal/logger"
+	"github.com/dyammarcano/application-manager/internal/metadata"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const adminShutdownTimeout = 5 * time.Second
+
+type (
+	// Status is the response of the admin status endpoint
+	Status struct {
+		Metadata *metadata.Metadata `json:"metadata"`
+		Services []string           `json:"services"`
+		Cache    *cache.Stats       `json:"cache,omitempty"`
+	}
+)
+
+// setupAdmin starts the admin http endpoint when an address is set in config or by command flag, the
+// address is bound before it returns so the startup fails on an address in use
+func (a *ManagerService) setupAdmin() error {
+	addr := a.v.GetString("admin-addr")
+	if addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", a.handleStatus)
+	mux.HandleFunc("/log/level", a.handleLogLevel)
+
+	a.admin = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	a.bgGroup.Add(2)
+	go func() {
+		defer a.bgGroup.Done()
+
+		managerLog.Info("admin endpoint listening", "addr", listener.Addr().String())
+		if err := a.admin.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			managerLog.Error("admin endpoint failed", "error", err)
+			a.causeFunc(err)
+		}
+	}()
+
+	go func() {
+		defer a.bgGroup.Done()
+
+		<-a.ctx.Done()
+		a.closeAdmin()
+	}()
+	return nil
+}
+
+// closeAdmin stops the admin http endpoint, the requests in progress are given adminShutdownTimeout to end
+func (a *ManagerService) closeAdmin() {
+	ctx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+	defer cancel()
+
+	if err := a.admin.Shutdown(ctx); err != nil {
+		managerLog.Error("failed to close admin endpoint", "error", err)
+	}
+}
+
+// status returns the current status of the service manager
+func (a *ManagerService) status() *Status {
+	a.mutex.RLock()
+	services := make([]string, 0, len(a.services))
+	for name := range a.services {
+		services = append(services, name)
+	}
+	a.mutex.RUnlock()
+
+	sort.Strings(services)
+
+	status := &Status{
+		Metadata: a.metadata,
+		Services: services,
+	}
+
+	if a.v3c != nil {
+		status.Cache = a.v3c.Stats()
+	}
+	return status
+}
+
+// handleStatus writes the service manager status as json
+func (a *ManagerService) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.status()); err != nil {
+		managerLog.Error("failed to write status", "error", err)
+	}
+}
+
+// handleLogLevel writes the log levels as json on GET and changes them on PUT, the body is merged with
+// the current levels and a service set to an empty level has its override removed
+func (a *ManagerService) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		levels := logger.GetLevels()
+		if err := json.NewDecoder(r.Body).Decode(levels); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for name, level := range levels.Services {
+			if level == "" {
+				delete(levels.Services, name)
+			}
+		}
+
+		if err := logger.SetLevels(levels); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		managerLog.Info("log level set", "level", levels.Level)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(logger.GetLevels()); err != nil {
+		managerLog.Error("failed to write log level", "error", err)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
-	"github.com/caarlos0/log"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dyammarcano/application-manager/internal/algorithm/encoding"
 	"github.com/dyammarcano/application-manager/internal/cache"
@@ -19,15 +19,28 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/automaxprocs/maxprocs"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"syscall"
 	"time"
 )
 
-var ms *ManagerService
+const (
+	defaultCacheGCInterval = 5 * time.Minute
+	cacheGCDiscardRatio    = 0.5
+)
+
+var (
+	ErrorSignal = fmt.Errorf("service: received signal to exit")
+
+	ms *ManagerService
+	// managerLog writes the lifecycle events of the service manager, its level can be set as the manager service
+	managerLog = logger.Service("manager")
+)
 
 func init() {
 	// enable colored output on github actions et al
@@ -38,16 +51,13 @@ func init() {
 	// automatically set GOMAXPROCS to match available CPUs.
 	// GOMAXPROCS will be used as the default value for the --parallelism flag.
 	if _, err := maxprocs.Set(); err != nil {
-		log.WithError(err).Warn("failed to set GOMAXPROCS")
+		managerLog.Warn("failed to set GOMAXPROCS", "error", err)
 	}
 
-	log.IncreasePadding()
-	log.Infof("starting service manager")
-
 	ms = &ManagerService{
-		errChan:  make(chan error),
+		done:     make(chan struct{}),
 		wGroup:   sync.WaitGroup{},
-		services: make(map[string]Runner),
+		services: make(map[string]RunnerContext),
 		mutex:    sync.RWMutex{},
 		v:        viper.New(),
 		options:  make([]command.State, 0),
@@ -57,17 +67,31 @@ func init() {
 type (
 	Runner func() error
 
+	// RunnerContext is a service receiving a context that carries its logger, see Logger
+	RunnerContext func(ctx context.Context) error
+
 	ManagerService struct {
 		errChan   chan error
 		ctx       context.Context
 		causeFunc context.CancelCauseFunc
 		wGroup    sync.WaitGroup
 		metadata  *metadata.Metadata
-		services  map[string]Runner
+		services  map[string]RunnerContext
 		mutex     sync.RWMutex
 		v3c       *cache.V3Cache
 		v         *viper.Viper
 		options   []command.State
+		command   *command.BuildCommand
+		closeOnce sync.Once
+		// done is closed once the shutdown completes
+		done chan struct{}
+		// startMutex keeps the services from being started once the context is cancelled
+		startMutex sync.Mutex
+		bgGroup    sync.WaitGroup
+		admin      *http.Server
+		logCfg     *logger.Config
+		// fileLoggers are the loggers of the services writing to their own file
+		fileLoggers []*logger.Logger
 	}
 )
 
@@ -76,20 +100,13 @@ func setup(ctx context.Context, version, commitHash, date string) {
 	ms.ctx, ms.causeFunc = context.WithCancelCause(ctx)
 	setupOsExitHandler(ms.ctx)
 	ms.metadata = initMetadata(version, commitHash, date)
-	ms.errorsHandler()
 }
 
-// AddFlag adds a flag to the service manager, it also binds the flag to the viper instance
+// AddFlag adds a flag to the service manager, it also binds the flag to the viper instance and to the
+// env var APPNAME_FLAG_NAME, the supported types are the ones of command.DefineFlag
 func AddFlag(cmd *cobra.Command, name string, defaultValue any, description string) {
-	switch v := defaultValue.(type) {
-	case bool:
-		cmd.PersistentFlags().Bool(name, v, description)
-	case string:
-		cmd.PersistentFlags().String(name, v, description)
-	case int, int8, int16, int32, int64:
-		cmd.PersistentFlags().Int64(name, v.(int64), description)
-	default:
-		fmt.Printf("Invalid type: %s\n", v)
+	if err := command.DefineFlag(cmd, true, name, defaultValue, description); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
@@ -97,6 +114,11 @@ func AddFlag(cmd *cobra.Command, name string, defaultValue any, description stri
 		cmd.Printf("Error binding flag: %s\n", err)
 		os.Exit(1)
 	}
+
+	if err := ms.v.BindEnv(name, command.EnvName(cmd.Root().Name(), name)); err != nil {
+		cmd.Printf("Error binding flag: %s\n", err)
+		os.Exit(1)
+	}
 }
 
 // GetValue returns the flag value
@@ -104,6 +126,17 @@ func GetValue(name string) any {
 	return ms.v.Get(name)
 }
 
+// GetString returns the flag value as a string
+func GetString(name string) string {
+	return ms.v.GetString(name)
+}
+
+// LoadConfig reads the config chosen like the service manager does before running the services, for the
+// commands resolving their settings, like the log directory, from it, see GetString
+func LoadConfig() error {
+	return ms.loadConfig()
+}
+
 // SetValue sets the flag value
 func SetValue(name string, value any) {
 	ms.v.Set(name, value)
@@ -115,14 +148,37 @@ func setupOsExitHandler(ctx context.Context) {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
+		defer signal.Stop(sigChan)
+
 		select {
 		case <-sigChan:
-			log.Info("receiving signal to gracefully exiting")
+			managerLog.Info("receiving signal to gracefully exiting")
+			// the cache is closed once the services stopped by the context return
+			ms.stopServices(ErrorSignal)
+			ms.wGroup.Wait()
+			ms.shutdown()
 			os.Exit(1)
-		case <-ctx.Done():
+		case <-ms.done:
 			return
 		}
 	}()
+
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(reloadChan)
+
+		for {
+			select {
+			case <-reloadChan:
+				managerLog.Info("receiving signal to reload config")
+				ms.reloadConfig()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 }
 
 // initMetadata initializes the metadata
@@ -154,11 +210,21 @@ func Context() context.Context {
 	return ms.ctx
 }
 
+// Cache returns the cache opened by the service manager, nil until the services are started
+func Cache() *cache.V3Cache {
+	return ms.v3c
+}
+
 // Execute creates a new service manager
 func Execute(ctx context.Context, version, commitHash, date string, buildCommand *command.BuildCommand) {
 	setup(ctx, version, commitHash, date)
+	ms.command = buildCommand
+	buildCommand.Bind(ms.v, buildCommand.Cmd.Name())
+	// cobra prints the error of the command
+	if err := buildCommand.Cmd.ExecuteContext(ms.ctx); err != nil {
+		os.Exit(1)
+	}
 	ms.options = buildCommand.Options
-	ms.errChan <- buildCommand.Cmd.ExecuteContext(ms.ctx)
 
 	if ms.v.GetBool("script") == true {
 		ms.generateScript()
@@ -175,10 +241,25 @@ func AppVersion() *metadata.Metadata {
 
 // RegisterService adds a service to the service to be executed
 func RegisterService(serviceName string, runner Runner) {
+	errAndExit("service instance is not initialized")
+	ms.registerService(serviceName, func(context.Context) error {
+		return runner()
+	})
+}
+
+// RegisterServiceContext adds a service to the service to be executed, the service receives a context
+// carrying its logger
+func RegisterServiceContext(serviceName string, runner RunnerContext) {
 	errAndExit("service instance is not initialized")
 	ms.registerService(serviceName, runner)
 }
 
+// Logger returns the logger of the service running with ctx, its entries carry the service, instance
+// and run_id fields, outside a service it returns a logger writing to the global logger
+func Logger(ctx context.Context) *logger.Logger {
+	return logger.FromContext(ctx)
+}
+
 // GetRandomValue returns a random guid like ulid, uuid, string, etc
 func GetRandomValue(name string) string {
 	switch name {
@@ -196,96 +277,349 @@ func GetRandomValue(name string) string {
 }
 
 // RegisterService adds a service to the service to be executed
-func (a *ManagerService) registerService(serviceName string, runner Runner) {
+func (a *ManagerService) registerService(serviceName string, runner RunnerContext) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
 	a.services[serviceName] = runner
-	log.Infof("service registered: [%s]", serviceName)
+	managerLog.Info("service registered", "name", serviceName)
 }
 
-// executeInGoRoutine executes a service in a go routine and returns the error in the error channel
-func (a *ManagerService) executeInGoRoutine(fn Runner) {
+// executeInGoRoutine executes a service in a go routine and returns the error in the error channel, the
+// channel is buffered for every service so the send does not block once the errors handler is done
+func (a *ManagerService) executeInGoRoutine(name string, fn RunnerContext) {
 	a.wGroup.Add(1)
 
+	ctx := logger.NewContext(a.ctx, a.serviceLogger(name))
+
 	go func() {
 		defer a.wGroup.Done()
 
-		log.Infof("ready=1")
-		log.DecreasePadding()
-		a.errChan <- fn()
+		managerLog.Info("service ready", "name", name)
+		a.errChan <- fn(ctx)
 	}()
 }
 
+// serviceLogger creates the logger of a service run, with its own file when log-per-service is set
+// in config or by command flag and the logs are written to a directory
+func (a *ManagerService) serviceLogger(name string) *logger.Logger {
+	fields := []any{"instance", a.instance(), "run_id", ulid.Make().String()}
+
+	if a.v.GetBool("log-per-service") && a.logCfg != nil && !a.logCfg.Stdout {
+		l, err := logger.NewServiceFileLogger(a.logCfg, name, fields...)
+		if err == nil {
+			a.mutex.Lock()
+			a.fileLoggers = append(a.fileLoggers, l)
+			a.mutex.Unlock()
+			return l
+		}
+		managerLog.Error("failed to create log file of service", "name", name, "error", err)
+	}
+	return logger.Service(name, fields...)
+}
+
+// instance returns the instance name set in config or by command flag, the host name by default
+func (a *ManagerService) instance() string {
+	if instance := a.v.GetString("instance"); instance != "" {
+		return instance
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "local"
+	}
+	return hostname
+}
+
 // runServices executes all the services registered in the service
 func (a *ManagerService) runServices() {
 	if len(a.services) > 0 {
-		a.chooseConfig()
-		a.setupLogger()
-		for name := range a.services {
-			if runner, exist := a.services[name]; exist {
-				log.Infof("starting service: [%s]", name)
-				a.executeInGoRoutine(runner)
-			}
+		if err := a.chooseConfig(); err != nil {
+			a.abort("failed to load config", err)
+		}
+		a.refreshOptions()
+		if err := a.validateConfig(); err != nil {
+			a.abort("invalid config", err)
+		}
+		if err := a.setupLogger(); err != nil {
+			a.abort("failed to setup logger", err)
+		}
+		managerLog.Info("starting service manager")
+		// the services expect the cache, they are not started without it
+		if err := a.setupCache(); err != nil {
+			a.abort("failed to open cache", err)
+		}
+		if err := a.setupAdmin(); err != nil {
+			a.abort("failed to start admin endpoint", err)
 		}
+		a.startServices()
 		a.wGroup.Wait()
+		close(a.errChan)
+		a.shutdown()
 	}
 }
 
-// chooseConfig chooses the config to be used
-func (a *ManagerService) chooseConfig() {
+// startServices executes the services unless the context is already cancelled, by a signal received
+// during the startup for instance
+func (a *ManagerService) startServices() {
+	a.startMutex.Lock()
+	defer a.startMutex.Unlock()
+
+	a.errChan = make(chan error, len(a.services))
+	a.errorsHandler()
+
+	if a.ctx.Err() != nil {
+		return
+	}
+
+	for name := range a.services {
+		if runner, exist := a.services[name]; exist {
+			managerLog.Info("starting service", "name", name)
+			a.executeInGoRoutine(name, runner)
+		}
+	}
+}
+
+// stopServices cancels the context of the services, no service is started after it
+func (a *ManagerService) stopServices(cause error) {
+	a.startMutex.Lock()
+	defer a.startMutex.Unlock()
+
+	a.causeFunc(cause)
+}
+
+// abort stops the startup of the service manager, it releases what was already set up and exits
+func (a *ManagerService) abort(msg string, err error) {
+	managerLog.Error(msg, "error", err)
+	a.causeFunc(err)
+	a.shutdown()
+	os.Exit(1)
+}
+
+// refreshOptions updates the flag options with the values read from the config
+func (a *ManagerService) refreshOptions() {
+	if a.command == nil {
+		return
+	}
+
+	a.command.Refresh(a.v)
+	a.options = a.command.Options
+}
+
+// validateConfig checks the flag rules, like the enums, with the values read from the config
+func (a *ManagerService) validateConfig() error {
+	if a.command == nil {
+		return nil
+	}
+	return a.command.Validate(a.v)
+}
+
+// setupCache opens the cache in the directory set in config or by command flag
+func (a *ManagerService) setupCache() error {
+	cacheDir := a.v.GetString("cache-dir")
+
+	if cacheDir == "" {
+		currPath, err := filepath.Abs(cache.DefaultDir)
+		if err != nil {
+			return err
+		}
+		cacheDir = currPath
+	}
+
+	opts := make([]cache.Option, 0, 1)
+	if a.v.IsSet("cache-key-id") {
+		opts = append(opts, cache.KeyringKey(a.v.GetInt("cache-key-id")))
+	}
+
+	v3c, err := cache.NewCache(cacheDir, opts...)
+	if err != nil {
+		return err
+	}
+
+	a.v3c = v3c
+	managerLog.Info("using cache", "dir", cacheDir)
+
+	a.bgGroup.Add(1)
+	go a.cacheGC()
+	return nil
+}
+
+// cacheGC runs the cache value log garbage collector periodically until the context is done
+func (a *ManagerService) cacheGC() {
+	defer a.bgGroup.Done()
+
+	interval := a.v.GetDuration("cache-gc-interval")
+	if interval <= 0 {
+		interval = defaultCacheGCInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.v3c.RunGC(cacheGCDiscardRatio); err != nil {
+				managerLog.Warn("cache garbage collection failed", "error", err)
+			}
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// shutdown releases the resources held by the service manager, it is safe to call more than once
+func (a *ManagerService) shutdown() {
+	a.closeOnce.Do(func() {
+		// stop the background workers and the admin endpoint before releasing what they use
+		a.causeFunc(context.Canceled)
+		a.bgGroup.Wait()
+
+		if a.v3c != nil {
+			if err := a.v3c.Close(); err != nil {
+				managerLog.Error("failed to close cache", "error", err)
+			}
+		}
+
+		// write the entries still buffered, like the pending dedup summaries, the sync error of a
+		// terminal is not relevant
+		a.mutex.RLock()
+		for _, l := range a.fileLoggers {
+			_ = l.Close()
+		}
+		a.mutex.RUnlock()
+		_ = logger.Sync()
+		close(a.done)
+	})
+}
+
+// chooseConfig chooses the config to be used, the config and config-string flags are mutually exclusive
+func (a *ManagerService) chooseConfig() error {
+	if err := a.loadConfig(); err != nil {
+		return err
+	}
+
 	go a.watchConfig()
+	return nil
+}
 
+// loadConfig reads the config string, the config file or the app.env file of the current path
+func (a *ManagerService) loadConfig() error {
 	configStr := ms.v.GetString("config-string")
 
 	if configStr != "" {
-		a.stringConfig(configStr)
-		return
+		return a.stringConfig(configStr)
 	}
 
 	cfgFile := ms.v.GetString("config")
 
 	if cfgFile == "" {
 		a.loadConfigFileEnv()
-		return
+		return nil
 	}
 
 	a.loadConfigFile(cfgFile)
+	return nil
 }
 
 // setupLogger check if logger are set in config or by commanf flag
-func (a *ManagerService) setupLogger() {
+func (a *ManagerService) setupLogger() error {
 	logPath := a.v.GetString("log-dir")
 
-	if logPath == "" {
-		if err := logger.NewLoggerDefault(); err != nil {
-			a.causeFunc(err)
+	cfg := logger.NewDefaultConfig()
+	if err := cfg.SetFormat(a.v.GetString("log-format"), a.v.GetString("log-time-format"), a.v.GetBool("log-caller"), a.v.GetBool("log-stacktrace")); err != nil {
+		return err
+	}
+
+	if err := cfg.SetLevel(a.v.GetString("log-level"), a.v.GetStringMapString("log-levels")); err != nil {
+		return err
+	}
+
+	if err := cfg.SetRedaction(a.v.GetStringSlice("log-redact-keys"), a.v.GetStringSlice("log-redact-patterns")); err != nil {
+		return err
+	}
+
+	cfg.SetSampling(a.v.GetDuration("log-sample-tick"), a.v.GetInt("log-sample-first"), a.v.GetInt("log-sample-thereafter"))
+	cfg.SetDedup(a.v.GetDuration("log-dedup"))
+
+	cfg.SetRetention(a.v.GetInt("log-max-total-size"))
+	if archiveDir := a.v.GetString("log-archive-dir"); archiveDir != "" {
+		cfg.SetArchiveDir(archiveDir)
+	}
+
+	if logPath != "" {
+		if err := cfg.SetPath(logPath, "", ""); err != nil {
+			return err
 		}
-		return
 	}
 
-	cfg := logger.NewDefaultConfig()
-	if err := cfg.SetPath(logPath, "", ""); err != nil {
-		a.causeFunc(err)
+	if err := a.setupLogSinks(cfg); err != nil {
+		return err
 	}
 
 	if err := logger.NewLogger(cfg); err != nil {
-		a.causeFunc(err)
+		return err
+	}
+	a.logCfg = cfg
+	return nil
+}
+
+// setupLogSinks adds the sinks listed under log-sinks in config, each one with a type (stdout, stderr,
+// file, syslog or journald) and optionally a level, an encoder, a socket address and a syslog tag
+func (a *ManagerService) setupLogSinks(cfg *logger.Config) error {
+	if !a.v.IsSet("log-sinks") {
+		return nil
+	}
+
+	var sinks []logger.Sink
+	if err := a.v.UnmarshalKey("log-sinks", &sinks); err != nil {
+		return err
+	}
+
+	for _, sink := range sinks {
+		if err := cfg.AddSink(sink); err != nil {
+			return err
+		}
+	}
+
+	// the file sink writes to the current directory when no log directory is set
+	return cfg.Validate()
+}
+
+// reloadConfig reads the config file again and applies the settings that can change at runtime
+func (a *ManagerService) reloadConfig() {
+	if a.v.ConfigFileUsed() != "" {
+		if err := a.v.ReadInConfig(); err != nil {
+			managerLog.Error("failed to reload config file", "error", err)
+			return
+		}
+	}
+
+	a.reloadLogLevels()
+}
+
+// reloadLogLevels applies the log level and the per-service overrides set in config or by command flag
+func (a *ManagerService) reloadLogLevels() {
+	levels := &logger.Levels{
+		Level:    a.v.GetString("log-level"),
+		Services: a.v.GetStringMapString("log-levels"),
+	}
+
+	if err := logger.SetLevels(levels); err != nil {
+		managerLog.Error("failed to set log level", "error", err)
+		return
 	}
+	managerLog.Info("log level set", "level", logger.GetLevels().Level)
 }
 
-// errorsHandler handles the errors in the error channel
+// errorsHandler handles the errors in the error channel until it is closed, once the services returned
 func (a *ManagerService) errorsHandler() {
 	go func() {
-		for {
-			select {
-			case err := <-a.errChan:
-				if err != nil {
-					fmt.Println(err)
-					a.causeFunc(err)
-				}
-			case <-a.ctx.Done():
-				return
+		for err := range a.errChan {
+			// the services stopped by the manager return the cancellation of their context
+			if err != nil && !(errors.Is(err, context.Canceled) && a.ctx.Err() != nil) {
+				managerLog.Error("service failed", "error", err)
+				a.causeFunc(err)
 			}
 		}
 	}()
@@ -299,7 +633,7 @@ func (a *ManagerService) loadConfigFileEnv() {
 	ms.v.AutomaticEnv()
 
 	if err := ms.v.ReadInConfig(); err == nil {
-		log.Infof("using config file: %s", ms.v.ConfigFileUsed())
+		managerLog.Info("using config file", "file", ms.v.ConfigFileUsed())
 	}
 }
 
@@ -309,21 +643,19 @@ func (a *ManagerService) loadConfigFile(cfgFile string) {
 	ms.v.AutomaticEnv()
 
 	if err := ms.v.ReadInConfig(); err == nil {
-		log.Infof("using config file: %s", ms.v.ConfigFileUsed())
+		managerLog.Info("using config file", "file", ms.v.ConfigFileUsed())
 	}
 }
 
 // stringConfig loads the config from a string
-func (a *ManagerService) stringConfig(data string) {
+func (a *ManagerService) stringConfig(data string) error {
 	deserialized, err := encoding.Deserialize(data)
 	if err != nil {
-		a.causeFunc(err)
+		return err
 	}
 
 	ms.v.SetConfigType("json")
-	if err = ms.v.ReadConfig(bytes.NewBuffer([]byte(deserialized))); err != nil {
-		a.causeFunc(err)
-	}
+	return ms.v.ReadConfig(bytes.NewBuffer([]byte(deserialized)))
 }
 
 // watchConfig watches the config file for changes
@@ -333,7 +665,7 @@ func (a *ManagerService) watchConfig() {
 	if ms.v.GetString("config-string") != "" {
 		ms.v.WatchConfig()
 		ms.v.OnConfigChange(func(e fsnotify.Event) {
-			log.Infof("config file changed:", e.Name)
+			managerLog.Info("config file changed", "file", e.Name)
 		})
 	}
 }
@@ -342,7 +674,7 @@ func (a *ManagerService) watchConfig() {
 func (a *ManagerService) generateScript() {
 	for name := range a.services {
 		if _, exist := a.services[name]; exist {
-			log.Infof("generating script for service: ", name)
+			managerLog.Info("generating script for service", "name", name)
 			// generate script
 			// get service name
 			// get service config and serialize it
@@ -352,20 +684,20 @@ func (a *ManagerService) generateScript() {
 }
 
 func (a *ManagerService) checkForUpdates() {
-	log.Infof("checking for updates")
+	managerLog.Info("checking for updates")
 	// check for updates
 	// download updates
 	// install updates
 }
 
 func (a *ManagerService) validateUpdate() {
-	log.Infof("validating updates")
+	managerLog.Info("validating updates")
 	// validate updates
 	// validate config
 }
 
 func (a *ManagerService) downloadUpdate() {
-	log.Infof("downloading updates")
+	managerLog.Info("downloading updates")
 	// download updates
 	// download config
 }